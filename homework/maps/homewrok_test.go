@@ -1,7 +1,11 @@
 package main
 
 import (
+	"cmp"
+	"iter"
+	"math/rand"
 	"reflect"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -9,36 +13,479 @@ import (
 
 // go test -v homework_test.go
 
-type OrderedMap struct {
-	// need to implement
+const (
+	red   = true
+	black = false
+)
+
+// owner marks nodes that can be changed in place, nodes of other
+// owners are shared with snapshots and have to be copied before changes
+type owner struct {
+	_ byte // pointers to zero-sized values can be equal
+}
+
+// left-leaning red-black tree node, size is a number of nodes in the subtree
+type node[K, V any] struct {
+	key   K
+	value V
+	left  *node[K, V]
+	right *node[K, V]
+	size  int
+	color bool
+	owner *owner
+}
+
+func isRed[K, V any](n *node[K, V]) bool {
+	return n != nil && n.color == red
+}
+
+func size[K, V any](n *node[K, V]) int {
+	if n == nil {
+		return 0
+	}
+
+	return n.size
+}
+
+func mutable[K, V any](n *node[K, V], o *owner) *node[K, V] {
+	if n == nil || n.owner == o {
+		return n
+	}
+
+	clone := *n
+	clone.owner = o
+	return &clone
+}
+
+// all helpers below expect h to be already owned by o
+
+func rotateLeft[K, V any](h *node[K, V], o *owner) *node[K, V] {
+	x := mutable(h.right, o)
+	h.right = x.left
+	x.left = h
+	x.color = h.color
+	h.color = red
+	x.size = h.size
+	h.size = 1 + size(h.left) + size(h.right)
+	return x
+}
+
+func rotateRight[K, V any](h *node[K, V], o *owner) *node[K, V] {
+	x := mutable(h.left, o)
+	h.left = x.right
+	x.right = h
+	x.color = h.color
+	h.color = red
+	x.size = h.size
+	h.size = 1 + size(h.left) + size(h.right)
+	return x
+}
+
+func flipColors[K, V any](h *node[K, V], o *owner) {
+	h.left = mutable(h.left, o)
+	h.right = mutable(h.right, o)
+
+	h.color = !h.color
+	h.left.color = !h.left.color
+	h.right.color = !h.right.color
+}
+
+func balance[K, V any](h *node[K, V], o *owner) *node[K, V] {
+	if isRed(h.right) && !isRed(h.left) {
+		h = rotateLeft(h, o)
+	}
+	if isRed(h.left) && isRed(h.left.left) {
+		h = rotateRight(h, o)
+	}
+	if isRed(h.left) && isRed(h.right) {
+		flipColors(h, o)
+	}
+
+	h.size = 1 + size(h.left) + size(h.right)
+	return h
+}
+
+func moveRedLeft[K, V any](h *node[K, V], o *owner) *node[K, V] {
+	flipColors(h, o)
+	if isRed(h.right.left) {
+		h.right = rotateRight(h.right, o)
+		h = rotateLeft(h, o)
+		flipColors(h, o)
+	}
+
+	return h
+}
+
+func moveRedRight[K, V any](h *node[K, V], o *owner) *node[K, V] {
+	flipColors(h, o)
+	if isRed(h.left.left) {
+		h = rotateRight(h, o)
+		flipColors(h, o)
+	}
+
+	return h
+}
+
+func minNode[K, V any](h *node[K, V]) *node[K, V] {
+	for h.left != nil {
+		h = h.left
+	}
+
+	return h
+}
+
+func deleteMin[K, V any](h *node[K, V], o *owner) *node[K, V] {
+	if h.left == nil {
+		return nil
+	}
+
+	h = mutable(h, o)
+	if !isRed(h.left) && !isRed(h.left.left) {
+		h = moveRedLeft(h, o)
+	}
+
+	h.left = deleteMin(h.left, o)
+	return balance(h, o)
+}
+
+// tree contains operations shared by mutable and persistent maps,
+// all operations take O(log n) in the worst case
+type tree[K, V any] struct {
+	root    *node[K, V]
+	compare func(K, K) int
+}
+
+func (t *tree[K, V]) insert(key K, value V, o *owner) {
+	t.root = t.insertNode(t.root, key, value, o)
+	t.root.color = black
+}
+
+func (t *tree[K, V]) insertNode(h *node[K, V], key K, value V, o *owner) *node[K, V] {
+	if h == nil {
+		return &node[K, V]{key: key, value: value, size: 1, color: red, owner: o}
+	}
+
+	h = mutable(h, o)
+	switch c := t.compare(key, h.key); {
+	case c < 0:
+		h.left = t.insertNode(h.left, key, value, o)
+	case c > 0:
+		h.right = t.insertNode(h.right, key, value, o)
+	default:
+		h.value = value
+	}
+
+	return balance(h, o)
 }
 
-func NewOrderedMap() OrderedMap {
-	return OrderedMap{} // need to implement
+func (t *tree[K, V]) erase(key K, o *owner) {
+	if !t.Contains(key) {
+		return
+	}
+
+	t.root = mutable(t.root, o)
+	if !isRed(t.root.left) && !isRed(t.root.right) {
+		t.root.color = red
+	}
+
+	t.root = t.eraseNode(t.root, key, o)
+	if t.root != nil {
+		t.root.color = black
+	}
+}
+
+// key must be present in the subtree
+func (t *tree[K, V]) eraseNode(h *node[K, V], key K, o *owner) *node[K, V] {
+	h = mutable(h, o)
+	if t.compare(key, h.key) < 0 {
+		if !isRed(h.left) && !isRed(h.left.left) {
+			h = moveRedLeft(h, o)
+		}
+		h.left = t.eraseNode(h.left, key, o)
+		return balance(h, o)
+	}
+
+	if isRed(h.left) {
+		h = rotateRight(h, o)
+	}
+	if t.compare(key, h.key) == 0 && h.right == nil {
+		return nil
+	}
+	if !isRed(h.right) && !isRed(h.right.left) {
+		h = moveRedRight(h, o)
+	}
+
+	if t.compare(key, h.key) == 0 {
+		successor := minNode(h.right)
+		h.key, h.value = successor.key, successor.value
+		h.right = deleteMin(h.right, o)
+	} else {
+		h.right = t.eraseNode(h.right, key, o)
+	}
+
+	return balance(h, o)
+}
+
+func (t tree[K, V]) find(key K) *node[K, V] {
+	n := t.root
+	for n != nil {
+		switch c := t.compare(key, n.key); {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return n
+		}
+	}
+
+	return nil
+}
+
+func (t tree[K, V]) Contains(key K) bool {
+	return t.find(key) != nil
+}
+
+func (t tree[K, V]) Get(key K) (V, bool) {
+	if n := t.find(key); n != nil {
+		return n.value, true
+	}
+
+	var zero V
+	return zero, false
+}
+
+func (t tree[K, V]) Size() int {
+	return size(t.root)
+}
+
+func unpack[K, V any](n *node[K, V]) (K, V, bool) {
+	if n == nil {
+		var key K
+		var value V
+		return key, value, false
+	}
+
+	return n.key, n.value, true
+}
+
+func (t tree[K, V]) Min() (K, V, bool) {
+	n := t.root
+	for n != nil && n.left != nil {
+		n = n.left
+	}
+
+	return unpack(n)
+}
+
+func (t tree[K, V]) Max() (K, V, bool) {
+	n := t.root
+	for n != nil && n.right != nil {
+		n = n.right
+	}
+
+	return unpack(n)
+}
+
+// Floor returns the greatest key less than or equal to key
+func (t tree[K, V]) Floor(key K) (K, V, bool) {
+	var floor *node[K, V]
+	for n := t.root; n != nil; {
+		switch c := t.compare(key, n.key); {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			floor, n = n, n.right
+		default:
+			return unpack(n)
+		}
+	}
+
+	return unpack(floor)
+}
+
+// Ceiling returns the least key greater than or equal to key
+func (t tree[K, V]) Ceiling(key K) (K, V, bool) {
+	var ceiling *node[K, V]
+	for n := t.root; n != nil; {
+		switch c := t.compare(key, n.key); {
+		case c < 0:
+			ceiling, n = n, n.left
+		case c > 0:
+			n = n.right
+		default:
+			return unpack(n)
+		}
+	}
+
+	return unpack(ceiling)
+}
+
+// Rank returns the number of keys strictly less than key
+func (t tree[K, V]) Rank(key K) int {
+	var rank int
+	for n := t.root; n != nil; {
+		switch c := t.compare(key, n.key); {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			rank += 1 + size(n.left)
+			n = n.right
+		default:
+			return rank + size(n.left)
+		}
+	}
+
+	return rank
+}
+
+// Select returns the entry with the given zero-based rank
+func (t tree[K, V]) Select(rank int) (K, V, bool) {
+	if rank < 0 || rank >= t.Size() {
+		return unpack[K, V](nil)
+	}
+
+	n := t.root
+	for {
+		left := size(n.left)
+		switch {
+		case rank < left:
+			n = n.left
+		case rank > left:
+			rank -= left + 1
+			n = n.right
+		default:
+			return unpack(n)
+		}
+	}
 }
 
-func (m *OrderedMap) Insert(key, value int) {
-	// need to implement
+func (t tree[K, V]) ascend(n *node[K, V], yield func(K, V) bool) bool {
+	if n == nil {
+		return true
+	}
+
+	return t.ascend(n.left, yield) && yield(n.key, n.value) && t.ascend(n.right, yield)
 }
 
-func (m *OrderedMap) Erase(key int) {
-	// need to implement
+func (t tree[K, V]) descend(n *node[K, V], yield func(K, V) bool) bool {
+	if n == nil {
+		return true
+	}
+
+	return t.descend(n.right, yield) && yield(n.key, n.value) && t.descend(n.left, yield)
+}
+
+func (t tree[K, V]) ascendRange(n *node[K, V], from, to K, yield func(K, V) bool) bool {
+	if n == nil {
+		return true
+	}
+
+	afterFrom := t.compare(n.key, from) >= 0
+	beforeTo := t.compare(n.key, to) < 0
+	if afterFrom && !t.ascendRange(n.left, from, to, yield) {
+		return false
+	}
+	if afterFrom && beforeTo && !yield(n.key, n.value) {
+		return false
+	}
+	if beforeTo {
+		return t.ascendRange(n.right, from, to, yield)
+	}
+
+	return true
 }
 
-func (m *OrderedMap) Contains(key int) bool {
-	return false // need to implement
+// All iterates over entries in ascending order of keys
+func (t tree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.ascend(t.root, yield)
+	}
 }
 
-func (m *OrderedMap) Size() int {
-	return 0 // need to implement
+// Backward iterates over entries in descending order of keys
+func (t tree[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.descend(t.root, yield)
+	}
 }
 
-func (m *OrderedMap) ForEach(action func(int, int)) {
-	// need to implement
+// Range iterates over entries with keys in [from, to) in ascending order
+func (t tree[K, V]) Range(from, to K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.ascendRange(t.root, from, to, yield)
+	}
+}
+
+func (t tree[K, V]) ForEach(action func(K, V)) {
+	t.ascend(t.root, func(key K, value V) bool {
+		action(key, value)
+		return true
+	})
+}
+
+// OrderedMapFunc keeps keys sorted by the compare function
+type OrderedMapFunc[K, V any] struct {
+	tree[K, V]
+	owner *owner
+}
+
+func NewOrderedMapFunc[K, V any](compare func(K, K) int) OrderedMapFunc[K, V] {
+	return OrderedMapFunc[K, V]{
+		tree:  tree[K, V]{compare: compare},
+		owner: new(owner),
+	}
+}
+
+func (m *OrderedMapFunc[K, V]) Insert(key K, value V) {
+	m.insert(key, value, m.owner)
+}
+
+func (m *OrderedMapFunc[K, V]) Erase(key K) {
+	m.erase(key, m.owner)
+}
+
+// Snapshot takes O(1): the tree becomes shared with the snapshot
+// and the map copies nodes on the path of the next changes
+func (m *OrderedMapFunc[K, V]) Snapshot() PersistentMap[K, V] {
+	m.owner = new(owner)
+	return PersistentMap[K, V]{m.tree}
+}
+
+type OrderedMap[K cmp.Ordered, V any] struct {
+	OrderedMapFunc[K, V]
+}
+
+func NewOrderedMap[K cmp.Ordered, V any]() OrderedMap[K, V] {
+	return OrderedMap[K, V]{NewOrderedMapFunc[K, V](cmp.Compare[K])}
+}
+
+// PersistentMap is immutable, every change returns a new version
+// that shares all unchanged nodes with the previous one
+type PersistentMap[K, V any] struct {
+	tree[K, V]
+}
+
+func NewPersistentMap[K cmp.Ordered, V any]() PersistentMap[K, V] {
+	return NewPersistentMapFunc[K, V](cmp.Compare[K])
+}
+
+func NewPersistentMapFunc[K, V any](compare func(K, K) int) PersistentMap[K, V] {
+	return PersistentMap[K, V]{tree[K, V]{compare: compare}}
+}
+
+func (m PersistentMap[K, V]) Insert(key K, value V) PersistentMap[K, V] {
+	m.insert(key, value, new(owner))
+	return m
+}
+
+func (m PersistentMap[K, V]) Erase(key K) PersistentMap[K, V] {
+	m.erase(key, new(owner))
+	return m
 }
 
 func TestCircularQueue(t *testing.T) {
-	data := NewOrderedMap()
+	data := NewOrderedMap[int, int]()
 	assert.Zero(t, data.Size())
 
 	data.Insert(10, 10)
@@ -81,3 +528,244 @@ func TestCircularQueue(t *testing.T) {
 
 	assert.True(t, reflect.DeepEqual(expectedKeys, keys))
 }
+
+func TestOrderedMapQueries(t *testing.T) {
+	data := NewOrderedMap[int, string]()
+	_, _, found := data.Min()
+	assert.False(t, found)
+
+	for _, key := range []int{10, 5, 15, 2, 4, 12, 14} {
+		data.Insert(key, string(rune('a'+key)))
+	}
+
+	data.Insert(4, "four")
+	value, found := data.Get(4)
+	assert.True(t, found)
+	assert.Equal(t, "four", value)
+	_, found = data.Get(3)
+	assert.False(t, found)
+
+	key, _, _ := data.Min()
+	assert.Equal(t, 2, key)
+	key, _, _ = data.Max()
+	assert.Equal(t, 15, key)
+
+	key, _, found = data.Floor(11)
+	assert.True(t, found)
+	assert.Equal(t, 10, key)
+	key, _, _ = data.Floor(12)
+	assert.Equal(t, 12, key)
+	_, _, found = data.Floor(1)
+	assert.False(t, found)
+
+	key, _, found = data.Ceiling(11)
+	assert.True(t, found)
+	assert.Equal(t, 12, key)
+	_, _, found = data.Ceiling(16)
+	assert.False(t, found)
+
+	assert.Equal(t, 0, data.Rank(2))
+	assert.Equal(t, 3, data.Rank(10))
+	assert.Equal(t, 4, data.Rank(11))
+	assert.Equal(t, 7, data.Rank(100))
+
+	key, _, found = data.Select(3)
+	assert.True(t, found)
+	assert.Equal(t, 10, key)
+	_, _, found = data.Select(7)
+	assert.False(t, found)
+
+	var keys []int
+	for key := range data.Range(4, 14) {
+		keys = append(keys, key)
+	}
+	assert.Equal(t, []int{4, 5, 10, 12}, keys)
+
+	keys = nil
+	for key := range data.Backward() {
+		keys = append(keys, key)
+	}
+	assert.Equal(t, []int{15, 14, 12, 10, 5, 4, 2}, keys)
+
+	keys = nil
+	for key := range data.All() {
+		if key > 5 {
+			break
+		}
+		keys = append(keys, key)
+	}
+	assert.Equal(t, []int{2, 4, 5}, keys)
+}
+
+func TestOrderedMapFunc(t *testing.T) {
+	data := NewOrderedMapFunc[string, int](func(lhs, rhs string) int {
+		return cmp.Compare(len(lhs), len(rhs))
+	})
+
+	data.Insert("ccc", 3)
+	data.Insert("a", 1)
+	data.Insert("bb", 2)
+	data.Insert("dd", 4) // same length as "bb"
+
+	var values []int
+	for _, value := range data.All() {
+		values = append(values, value)
+	}
+
+	assert.Equal(t, []int{1, 4, 3}, values)
+}
+
+// checks that every path from the root has the same number of black links
+// and that red links lean left, returns the black height
+func checkInvariants[K, V any](t *testing.T, n *node[K, V]) int {
+	if n == nil {
+		return 0
+	}
+
+	assert.False(t, isRed(n.right))
+	assert.False(t, isRed(n) && isRed(n.left))
+	assert.Equal(t, 1+size(n.left)+size(n.right), n.size)
+
+	left := checkInvariants(t, n.left)
+	right := checkInvariants(t, n.right)
+	assert.Equal(t, left, right)
+
+	if n.color == black {
+		return left + 1
+	}
+
+	return left
+}
+
+func TestOrderedMapBalance(t *testing.T) {
+	data := NewOrderedMap[int, int]()
+	expected := map[int]int{}
+
+	random := rand.New(rand.NewSource(42))
+	for i := 0; i < 10000; i++ {
+		key := random.Intn(1000)
+		if random.Intn(3) == 0 {
+			data.Erase(key)
+			delete(expected, key)
+		} else {
+			data.Insert(key, i)
+			expected[key] = i
+		}
+	}
+
+	assert.False(t, isRed(data.root))
+	checkInvariants(t, data.root)
+	assert.Equal(t, len(expected), data.Size())
+
+	keys := make([]int, 0, len(expected))
+	for key := range expected {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	var actual []int
+	for key, value := range data.All() {
+		assert.Equal(t, expected[key], value)
+		actual = append(actual, key)
+	}
+	assert.Equal(t, keys, actual)
+
+	for rank, key := range keys {
+		selected, _, _ := data.Select(rank)
+		assert.Equal(t, key, selected)
+		assert.Equal(t, rank, data.Rank(key))
+	}
+}
+
+func collectKeys[K, V any](seq iter.Seq2[K, V]) []K {
+	var keys []K
+	for key := range seq {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+func TestPersistentMap(t *testing.T) {
+	empty := NewPersistentMap[int, string]()
+	version1 := empty.Insert(1, "a").Insert(2, "b").Insert(3, "c")
+	version2 := version1.Insert(4, "d").Erase(1)
+	version3 := version2.Insert(2, "bb")
+
+	assert.Zero(t, empty.Size())
+	assert.Equal(t, []int{1, 2, 3}, collectKeys(version1.All()))
+	assert.Equal(t, []int{2, 3, 4}, collectKeys(version2.All()))
+
+	value, _ := version2.Get(2)
+	assert.Equal(t, "b", value)
+	value, _ = version3.Get(2)
+	assert.Equal(t, "bb", value)
+
+	// untouched subtrees are shared between versions
+	assert.Same(t, version2.find(4), version3.find(4))
+}
+
+func TestOrderedMapSnapshot(t *testing.T) {
+	data := NewOrderedMap[int, int]()
+	for i := 0; i < 100; i++ {
+		data.Insert(i, i)
+	}
+
+	snapshot := data.Snapshot()
+	assert.Same(t, data.root, snapshot.root)
+
+	for i := 0; i < 100; i += 2 {
+		data.Erase(i)
+	}
+	data.Insert(1, -1)
+	data.Insert(1000, 1000)
+
+	assert.Equal(t, 51, data.Size())
+	assert.Equal(t, 100, snapshot.Size())
+	checkInvariants(t, data.root)
+	checkInvariants(t, snapshot.root)
+
+	for i := 0; i < 100; i++ {
+		value, found := snapshot.Get(i)
+		assert.True(t, found)
+		assert.Equal(t, i, value)
+	}
+
+	value, _ := data.Get(1)
+	assert.Equal(t, -1, value)
+}
+
+func TestPersistentMapVersions(t *testing.T) {
+	versions := []PersistentMap[int, int]{NewPersistentMap[int, int]()}
+	expected := []map[int]int{{}}
+
+	random := rand.New(rand.NewSource(42))
+	for i := 0; i < 2000; i++ {
+		previous := len(versions) - 1 - random.Intn(min(len(versions), 10))
+		state := make(map[int]int, len(expected[previous]))
+		for key, value := range expected[previous] {
+			state[key] = value
+		}
+
+		key := random.Intn(200)
+		version := versions[previous]
+		if random.Intn(3) == 0 {
+			version = version.Erase(key)
+			delete(state, key)
+		} else {
+			version = version.Insert(key, i)
+			state[key] = i
+		}
+
+		versions = append(versions, version)
+		expected = append(expected, state)
+	}
+
+	for idx, version := range versions {
+		checkInvariants(t, version.root)
+		assert.Equal(t, len(expected[idx]), version.Size())
+		for key, value := range version.All() {
+			assert.Equal(t, expected[idx][key], value)
+		}
+	}
+}
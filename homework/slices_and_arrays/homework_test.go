@@ -1,7 +1,11 @@
 package main
 
 import (
+	"iter"
 	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -9,37 +13,223 @@ import (
 
 // go test -v homework_test.go
 
-type CircularQueue struct {
-	values []int
-	// need to implement
+type RingMode int
+
+const (
+	Fixed     RingMode = iota // Push fails when the ring is full
+	Grow                      // capacity is doubled when the ring is full
+	Overwrite                 // the oldest value is dropped when the ring is full
+)
+
+type Ring[T any] struct {
+	values []T
+	head   int // index of the front value
+	size   int
+	mode   RingMode
 }
 
-func NewCircularQueue(size int) CircularQueue {
-	return CircularQueue{} // need to implement
+func NewRing[T any](capacity int, mode RingMode) Ring[T] {
+	return Ring[T]{
+		values: make([]T, capacity),
+		mode:   mode,
+	}
+}
+
+func (r *Ring[T]) index(offset int) int {
+	return (r.head + offset) % len(r.values)
+}
+
+func (r *Ring[T]) grow() {
+	values := make([]T, max(2*len(r.values), 1))
+	n := copy(values, r.values[r.head:])
+	copy(values[n:], r.values[:r.head])
+
+	r.values = values
+	r.head = 0
+}
+
+func (r *Ring[T]) Push(value T) bool {
+	if r.Full() {
+		switch {
+		case r.mode == Grow:
+			r.grow()
+		case r.mode == Overwrite && len(r.values) != 0:
+			r.values[r.head] = value
+			r.head = r.index(1)
+			return true
+		default:
+			return false
+		}
+	}
+
+	r.values[r.index(r.size)] = value
+	r.size++
+	return true
+}
+
+// PushN returns the number of pushed values
+func (r *Ring[T]) PushN(values ...T) int {
+	for idx, value := range values {
+		if !r.Push(value) {
+			return idx
+		}
+	}
+
+	return len(values)
+}
+
+func (r *Ring[T]) Pop() (T, bool) {
+	var zero T
+	if r.Empty() {
+		return zero, false
+	}
+
+	value := r.values[r.head]
+	r.values[r.head] = zero // don't hold a reference for GC
+	r.head = r.index(1)
+	r.size--
+	return value, true
 }
 
-func (q *CircularQueue) Push(value int) bool {
-	return false // need to implement
+// PopN moves front values to dst and returns the number of moved values
+func (r *Ring[T]) PopN(dst []T) int {
+	n := min(len(dst), r.size)
+	for idx := 0; idx < n; idx++ {
+		dst[idx], _ = r.Pop()
+	}
+
+	return n
+}
+
+func (r *Ring[T]) Front() (T, bool) {
+	if r.Empty() {
+		var zero T
+		return zero, false
+	}
+
+	return r.values[r.head], true
+}
+
+func (r *Ring[T]) Back() (T, bool) {
+	if r.Empty() {
+		var zero T
+		return zero, false
+	}
+
+	return r.values[r.index(r.size-1)], true
+}
+
+func (r *Ring[T]) Len() int {
+	return r.size
+}
+
+func (r *Ring[T]) Cap() int {
+	return len(r.values)
+}
+
+func (r *Ring[T]) Empty() bool {
+	return r.size == 0
+}
+
+func (r *Ring[T]) Full() bool {
+	return r.size == len(r.values)
+}
+
+// All iterates over values from the front to the back
+func (r *Ring[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for offset := 0; offset < r.size; offset++ {
+			if !yield(offset, r.values[r.index(offset)]) {
+				return
+			}
+		}
+	}
+}
+
+type CircularQueue struct {
+	Ring[int]
+}
+
+func NewCircularQueue(size int) CircularQueue {
+	return CircularQueue{NewRing[int](size, Fixed)}
 }
 
 func (q *CircularQueue) Pop() bool {
-	return false // need to implement
+	_, found := q.Ring.Pop()
+	return found
 }
 
 func (q *CircularQueue) Front() int {
-	return -1 // need to implement
+	if value, found := q.Ring.Front(); found {
+		return value
+	}
+
+	return -1
 }
 
 func (q *CircularQueue) Back() int {
-	return -1 // need to implement
+	if value, found := q.Ring.Back(); found {
+		return value
+	}
+
+	return -1
 }
 
-func (q *CircularQueue) Empty() bool {
-	return false // need to implement
+const cacheLineSize = 64
+
+// SPSCRing is a lock-free ring for exactly one producer and one consumer goroutine
+type SPSCRing[T any] struct {
+	values []T
+	mask   uint64
+	_      [cacheLineSize]byte
+	head   atomic.Uint64 // changed only by the consumer
+	_      [cacheLineSize]byte
+	tail   atomic.Uint64 // changed only by the producer
+	_      [cacheLineSize]byte
 }
 
-func (q *CircularQueue) Full() bool {
-	return false // need to implement
+// NewSPSCRing rounds the capacity up to a power of two
+func NewSPSCRing[T any](capacity int) *SPSCRing[T] {
+	size := 1
+	for size < capacity {
+		size <<= 1
+	}
+
+	return &SPSCRing[T]{
+		values: make([]T, size),
+		mask:   uint64(size - 1),
+	}
+}
+
+func (r *SPSCRing[T]) Push(value T) bool {
+	tail := r.tail.Load()
+	if tail-r.head.Load() == uint64(len(r.values)) {
+		return false
+	}
+
+	r.values[tail&r.mask] = value
+	r.tail.Store(tail + 1) // publishes the value to the consumer
+	return true
+}
+
+func (r *SPSCRing[T]) Pop() (T, bool) {
+	var zero T
+	head := r.head.Load()
+	if head == r.tail.Load() {
+		return zero, false
+	}
+
+	value := r.values[head&r.mask]
+	r.values[head&r.mask] = zero
+	r.head.Store(head + 1) // returns the slot to the producer
+	return value, true
+}
+
+// Len is approximate under concurrent use, head is loaded first,
+// so a pop between the loads can't make it negative
+func (r *SPSCRing[T]) Len() int {
+	head := r.head.Load()
+	return int(r.tail.Load() - head)
 }
 
 func TestCircularQueue(t *testing.T) {
@@ -84,3 +274,99 @@ func TestCircularQueue(t *testing.T) {
 	assert.True(t, queue.Empty())
 	assert.False(t, queue.Full())
 }
+
+func collect[T any](r *Ring[T]) []T {
+	var values []T
+	for _, value := range r.All() {
+		values = append(values, value)
+	}
+
+	return values
+}
+
+func TestRingGrow(t *testing.T) {
+	ring := NewRing[string](2, Grow)
+	assert.Equal(t, 4, ring.PushN("a", "b", "c", "d"))
+	assert.Equal(t, 4, ring.Cap())
+
+	value, _ := ring.Pop()
+	assert.Equal(t, "a", value)
+	assert.True(t, ring.Push("e"))
+	assert.True(t, ring.Push("f"))
+	assert.Equal(t, 8, ring.Cap())
+	assert.Equal(t, []string{"b", "c", "d", "e", "f"}, collect(&ring))
+
+	front, _ := ring.Front()
+	back, _ := ring.Back()
+	assert.Equal(t, "b", front)
+	assert.Equal(t, "f", back)
+
+	empty := NewRing[string](0, Grow)
+	assert.True(t, empty.Push("a"))
+	assert.Equal(t, 1, empty.Cap())
+}
+
+func TestRingOverwrite(t *testing.T) {
+	ring := NewRing[int](3, Overwrite)
+	assert.Equal(t, 5, ring.PushN(1, 2, 3, 4, 5))
+	assert.Equal(t, 3, ring.Len())
+	assert.Equal(t, []int{3, 4, 5}, collect(&ring))
+
+	dst := make([]int, 2)
+	assert.Equal(t, 2, ring.PopN(dst))
+	assert.Equal(t, []int{3, 4}, dst)
+	assert.Equal(t, []int{5}, collect(&ring))
+
+	assert.Equal(t, 1, ring.PopN(dst))
+	assert.True(t, ring.Empty())
+	_, found := ring.Front()
+	assert.False(t, found)
+}
+
+func TestRingFixed(t *testing.T) {
+	ring := NewRing[int](3, Fixed)
+	assert.Equal(t, 3, ring.PushN(1, 2, 3, 4))
+	assert.True(t, ring.Full())
+
+	for idx, value := range ring.All() {
+		if idx == 2 {
+			break
+		}
+		assert.Equal(t, idx+1, value)
+	}
+}
+
+func TestSPSCRing(t *testing.T) {
+	const count = 100000
+	ring := NewSPSCRing[int](100)
+	assert.Len(t, ring.values, 128)
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < count; {
+			if ring.Push(i) {
+				i++
+			} else {
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for expected := 0; expected < count; {
+			if value, found := ring.Pop(); found {
+				assert.Equal(t, expected, value)
+				expected++
+			} else {
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	wg.Wait()
+	assert.Zero(t, ring.Len())
+}
@@ -2,36 +2,141 @@ package main
 
 import (
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"unsafe"
 
 	"github.com/stretchr/testify/assert"
 )
 
-type COWBuffer struct {
-	data []byte
-	refs *int
-	// need to implement
+// COWDebug makes every handle panic when it is used after Close,
+// otherwise closed handles are empty and changing them does nothing
+var COWDebug = false
+
+// COW is a copy-on-write slice, handles are cheap to clone
+// and can be passed to other goroutines, but a single handle
+// must not be used by several goroutines at once
+type COW[T any] struct {
+	data   []T
+	refs   *atomic.Int32 // shared by all handles of the same data
+	closed bool
 }
 
-func NewCOWBuffer(data []byte) COWBuffer {
-	return COWBuffer{} // need to implement
+func NewCOW[T any](data []T) COW[T] {
+	refs := new(atomic.Int32)
+	refs.Store(1)
+
+	return COW[T]{
+		data: data,
+		refs: refs,
+	}
 }
 
-func (b *COWBuffer) Clone() COWBuffer {
-	return COWBuffer{} // need to implement
+// check reports whether the handle isn't closed
+func (c *COW[T]) check() bool {
+	if COWDebug && c.closed {
+		panic("cow: use after close")
+	}
+
+	return !c.closed
+}
+
+func (c *COW[T]) Clone() COW[T] {
+	if !c.check() {
+		return COW[T]{closed: true}
+	}
+
+	c.refs.Add(1)
+	return COW[T]{
+		data: c.data,
+		refs: c.refs,
+	}
+}
+
+// Slice returns a handle to the part of the data without copying
+func (c *COW[T]) Slice(from, to int) COW[T] {
+	if !c.check() {
+		return COW[T]{closed: true}
+	}
+
+	c.refs.Add(1)
+	return COW[T]{
+		data: c.data[from:to:to],
+		refs: c.refs,
+	}
+}
+
+// Close can be called many times, but only the first call releases the data
+func (c *COW[T]) Close() {
+	if c.closed {
+		c.check()
+		return
+	}
+
+	c.refs.Add(-1)
+	c.refs = nil
+	c.data = nil
+	c.closed = true
+}
+
+// Detach makes the data owned only by this handle
+func (c *COW[T]) Detach() {
+	if !c.check() || c.refs.Load() == 1 {
+		return
+	}
+
+	c.data = append([]T(nil), c.data...)
+	c.refs.Add(-1)
+	c.refs = new(atomic.Int32)
+	c.refs.Store(1)
+}
+
+func (c *COW[T]) Update(index int, value T) bool {
+	if !c.check() || index < 0 || index >= len(c.data) {
+		return false
+	}
+
+	c.Detach()
+	c.data[index] = value
+	return true
+}
+
+func (c *COW[T]) Append(values ...T) {
+	if !c.check() {
+		return
+	}
+
+	c.Detach()
+	c.data = append(c.data, values...)
+}
+
+func (c *COW[T]) Get(index int) T {
+	c.check()
+	return c.data[index]
+}
+
+func (c *COW[T]) Len() int {
+	c.check()
+	return len(c.data)
 }
 
-func (b *COWBuffer) Close() {
-	// need to implement
+type COWBuffer struct {
+	COW[byte]
 }
 
-func (b *COWBuffer) Update(index int, value byte) bool {
-	return false // need to implement
+func NewCOWBuffer(data []byte) COWBuffer {
+	return COWBuffer{NewCOW(data)}
 }
 
+func (b *COWBuffer) Clone() COWBuffer {
+	return COWBuffer{b.COW.Clone()}
+}
+
+// String doesn't copy the data, so the result is valid until the next change
 func (b *COWBuffer) String() string {
-	return "" // need to implement
+	b.check()
+	return unsafe.String(unsafe.SliceData(b.data), len(b.data))
 }
 
 func TestCOWBuffer(t *testing.T) {
@@ -72,3 +177,95 @@ func TestCOWBuffer(t *testing.T) {
 
 	copy2.Close()
 }
+
+func TestCOWAppendAndSlice(t *testing.T) {
+	data := NewCOW([]int{1, 2, 3, 4})
+	defer data.Close()
+
+	part := data.Slice(1, 3)
+	assert.Equal(t, int32(2), data.refs.Load())
+	assert.Same(t, unsafe.SliceData(data.data[1:]), unsafe.SliceData(part.data))
+
+	// appending to the part doesn't overwrite the original data
+	part.Append(5)
+	assert.Equal(t, []int{2, 3, 5}, part.data)
+	assert.Equal(t, []int{1, 2, 3, 4}, data.data)
+	assert.Equal(t, int32(1), data.refs.Load())
+	assert.Equal(t, int32(1), part.refs.Load())
+
+	part.Close()
+	part.Close()
+	assert.Equal(t, int32(1), data.refs.Load())
+
+	previous := unsafe.SliceData(data.data)
+	data.Detach()
+	assert.Same(t, previous, unsafe.SliceData(data.data))
+	assert.Equal(t, 4, data.Len())
+	assert.Equal(t, 3, data.Get(2))
+}
+
+func TestCOWConcurrentClones(t *testing.T) {
+	const goroutines = 8
+
+	data := NewCOW([]int{0, 0, 0})
+	defer data.Close()
+
+	wg := sync.WaitGroup{}
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		clone := data.Clone()
+		go func() {
+			defer wg.Done()
+			defer clone.Close()
+
+			for j := 0; j < 100; j++ {
+				nested := clone.Clone()
+				nested.Update(0, i)
+				nested.Close()
+			}
+
+			clone.Update(1, i)
+			assert.Equal(t, i, clone.Get(1))
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, []int{0, 0, 0}, data.data)
+	assert.Equal(t, int32(1), data.refs.Load())
+}
+
+func TestCOWClosedHandle(t *testing.T) {
+	a := NewCOW([]int{1, 2, 3})
+	defer a.Close()
+	b := a.Clone()
+	c := a.Clone()
+	defer c.Close()
+
+	b.Close()
+	b.Append(4)
+	b.Detach()
+	assert.False(t, b.Update(0, 5))
+	assert.Zero(t, b.Len())
+	assert.Equal(t, int32(2), a.refs.Load())
+
+	clone := b.Clone()
+	assert.Zero(t, clone.Len())
+	clone.Close()
+
+	a.Update(0, 9)
+	assert.Equal(t, []int{9, 2, 3}, a.data)
+	assert.Equal(t, []int{1, 2, 3}, c.data)
+}
+
+func TestCOWDebug(t *testing.T) {
+	COWDebug = true
+	defer func() { COWDebug = false }()
+
+	data := NewCOW([]byte("abc"))
+	data.Close()
+
+	assert.Panics(t, func() { data.Update(0, 'd') })
+	assert.Panics(t, func() { data.Append('d') })
+	assert.Panics(t, func() { data.Close() })
+}
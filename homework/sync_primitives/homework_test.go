@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -8,25 +10,289 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+type Policy int
+
+const (
+	WriterPreferring Policy = iota // new readers wait while there is a waiting writer
+	ReaderPreferring               // new readers don't wait for waiting writers
+	FIFO                           // waiters are served in arrival order, consecutive readers share the lock
+)
+
+type waiter struct {
+	write bool
+	since time.Time
+	ready chan struct{} // closed when the lock is granted
+}
+
+// RWMutex queues waiters, so they can leave the queue when a context is done,
+// the zero value prefers writers. Like sync.Mutex it switches to FIFO order
+// while the oldest waiter waits longer than the starvation threshold
 type RWMutex struct {
-	// need to implement
+	mutex     sync.Mutex
+	policy    Policy
+	threshold time.Duration // 0 disables starvation mode
+	starving  bool
+
+	readers        int
+	writer         bool
+	waitingWriters int
+	queue          []*waiter
+}
+
+func NewRWMutex(policy Policy, starvationThreshold time.Duration) *RWMutex {
+	return &RWMutex{
+		policy:    policy,
+		threshold: starvationThreshold,
+	}
+}
+
+func (m *RWMutex) currentPolicy() Policy {
+	if m.starving {
+		return FIFO
+	}
+
+	return m.policy
+}
+
+func (m *RWMutex) updateStarvation() {
+	if m.threshold == 0 {
+		return
+	}
+
+	if len(m.queue) == 0 {
+		m.starving = false
+	} else if time.Since(m.queue[0].since) > m.threshold {
+		m.starving = true
+	}
+}
+
+func (m *RWMutex) canRead() bool {
+	if m.writer {
+		return false
+	}
+
+	m.updateStarvation()
+	switch m.currentPolicy() {
+	case ReaderPreferring:
+		return true
+	case FIFO:
+		return len(m.queue) == 0
+	default:
+		return m.waitingWriters == 0
+	}
+}
+
+func (m *RWMutex) canWrite() bool {
+	return !m.writer && m.readers == 0 && len(m.queue) == 0
+}
+
+func (m *RWMutex) enqueue(write bool) *waiter {
+	w := &waiter{write: write, since: time.Now(), ready: make(chan struct{})}
+	m.queue = append(m.queue, w)
+	if write {
+		m.waitingWriters++
+	}
+
+	return w
+}
+
+func (m *RWMutex) remove(idx int) {
+	if m.queue[idx].write {
+		m.waitingWriters--
+	}
+
+	m.queue = append(m.queue[:idx], m.queue[idx+1:]...)
+}
+
+func (m *RWMutex) grantWriter(idx int) {
+	w := m.queue[idx]
+	m.remove(idx)
+	m.writer = true
+	close(w.ready)
+}
+
+func (m *RWMutex) grantReaders(all bool) {
+	for idx := 0; idx < len(m.queue); {
+		w := m.queue[idx]
+		if w.write {
+			if !all {
+				return
+			}
+
+			idx++
+			continue
+		}
+
+		m.remove(idx)
+		m.readers++
+		close(w.ready)
+	}
+}
+
+// grant passes the lock to waiters after it was released
+func (m *RWMutex) grant() {
+	if m.writer {
+		return
+	}
+
+	m.updateStarvation()
+	switch m.currentPolicy() {
+	case ReaderPreferring:
+		m.grantReaders(true)
+	case FIFO:
+		m.grantReaders(false)
+	default:
+		if m.waitingWriters == 0 {
+			m.grantReaders(true)
+		}
+	}
+
+	if m.readers != 0 {
+		return
+	}
+
+	for idx, w := range m.queue {
+		if w.write {
+			m.grantWriter(idx)
+			return
+		}
+	}
+}
+
+// cancel removes the waiter from the queue,
+// returns false if the lock has already been granted
+func (m *RWMutex) cancel(w *waiter) bool {
+	for idx, queued := range m.queue {
+		if queued == w {
+			m.remove(idx)
+			m.grant() // readers could wait for this writer
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *RWMutex) wait(ctx context.Context, w *waiter) error {
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+
+		if m.cancel(w) {
+			return ctx.Err()
+		}
+
+		return nil // the lock was granted at the same time
+	}
 }
 
 func (m *RWMutex) Lock() {
-	// need to implement
+	_ = m.LockContext(context.Background())
+}
+
+// LockContext returns an error without holding the lock if ctx is done before the lock is acquired
+func (m *RWMutex) LockContext(ctx context.Context) error {
+	m.mutex.Lock()
+	if err := ctx.Err(); err != nil {
+		m.mutex.Unlock()
+		return err
+	}
+
+	if m.canWrite() {
+		m.writer = true
+		m.mutex.Unlock()
+		return nil
+	}
+
+	w := m.enqueue(true)
+	m.mutex.Unlock()
+
+	return m.wait(ctx, w)
+}
+
+func (m *RWMutex) TryLock() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.canWrite() {
+		return false
+	}
+
+	m.writer = true
+	return true
 }
 
 func (m *RWMutex) Unlock() {
-	// need to implement
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.writer {
+		panic("sync: Unlock of unlocked RWMutex")
+	}
+
+	m.writer = false
+	m.grant()
 }
 
 func (m *RWMutex) RLock() {
-	// need to implement
+	_ = m.RLockContext(context.Background())
+}
+
+// RLockContext returns an error without holding the lock if ctx is done before the lock is acquired
+func (m *RWMutex) RLockContext(ctx context.Context) error {
+	m.mutex.Lock()
+	if err := ctx.Err(); err != nil {
+		m.mutex.Unlock()
+		return err
+	}
 
+	if m.canRead() {
+		m.readers++
+		m.mutex.Unlock()
+		return nil
+	}
+
+	w := m.enqueue(false)
+	m.mutex.Unlock()
+
+	return m.wait(ctx, w)
+}
+
+func (m *RWMutex) TryRLock() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.canRead() {
+		return false
+	}
+
+	m.readers++
+	return true
 }
 
 func (m *RWMutex) RUnlock() {
-	// need to implement
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.readers == 0 {
+		panic("sync: RUnlock of unlocked RWMutex")
+	}
+
+	m.readers--
+	m.grant()
+}
+
+type rlocker RWMutex
+
+func (r *rlocker) Lock()   { (*RWMutex)(r).RLock() }
+func (r *rlocker) Unlock() { (*RWMutex)(r).RUnlock() }
+
+// RLocker returns a Locker interface that implements Lock and Unlock by calling RLock and RUnlock
+func (m *RWMutex) RLocker() sync.Locker {
+	return (*rlocker)(m)
 }
 
 func TestRWMutexWithWriter(t *testing.T) {
@@ -121,3 +387,267 @@ func TestRWMutexWithWriterPriority(t *testing.T) {
 	assert.True(t, mutualExlusionWithWriter.Load())
 	assert.Equal(t, int32(1), readersCount.Load())
 }
+
+func TestRWMutexTryLock(t *testing.T) {
+	var mutex RWMutex
+	assert.True(t, mutex.TryRLock())
+	assert.True(t, mutex.TryRLock())
+	assert.False(t, mutex.TryLock())
+
+	mutex.RUnlock()
+	mutex.RUnlock()
+	assert.True(t, mutex.TryLock())
+	assert.False(t, mutex.TryLock())
+	assert.False(t, mutex.TryRLock())
+
+	mutex.Unlock()
+	assert.Panics(t, func() { mutex.Unlock() })
+	assert.Panics(t, func() { mutex.RUnlock() })
+}
+
+func TestRWMutexLockContext(t *testing.T) {
+	var mutex RWMutex
+	mutex.RLock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, mutex.LockContext(ctx), context.DeadlineExceeded)
+	assert.ErrorIs(t, mutex.RLockContext(ctx), context.DeadlineExceeded) // done context
+
+	// the writer left the queue, so readers are not blocked anymore
+	assert.True(t, mutex.TryRLock())
+	mutex.RUnlock()
+	mutex.RUnlock()
+
+	assert.NoError(t, mutex.LockContext(context.Background()))
+}
+
+func TestRWMutexRLockContext(t *testing.T) {
+	var mutex RWMutex
+	mutex.Lock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	assert.ErrorIs(t, mutex.RLockContext(ctx), context.Canceled)
+
+	var readersCount atomic.Int32
+	go func() {
+		assert.NoError(t, mutex.RLockContext(context.Background()))
+		readersCount.Add(1)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Zero(t, readersCount.Load())
+
+	mutex.Unlock()
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(1), readersCount.Load())
+}
+
+func TestRWMutexRLocker(t *testing.T) {
+	var mutex RWMutex
+	locker := mutex.RLocker()
+
+	locker.Lock()
+	locker.Lock()
+	assert.False(t, mutex.TryLock())
+
+	locker.Unlock()
+	locker.Unlock()
+	assert.True(t, mutex.TryLock())
+}
+
+func TestRWMutexConcurrentAccess(t *testing.T) {
+	var mutex RWMutex
+	var value int
+
+	wg := sync.WaitGroup{}
+	wg.Add(20)
+	for i := 0; i < 10; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				mutex.Lock()
+				value++
+				mutex.Unlock()
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Microsecond)
+				if mutex.RLockContext(ctx) == nil {
+					_ = value
+					mutex.RUnlock()
+				}
+				cancel()
+			}
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, 1000, value)
+}
+
+func TestRWMutexReaderPreferring(t *testing.T) {
+	mutex := NewRWMutex(ReaderPreferring, 0)
+	mutex.RLock()
+
+	var writersCount atomic.Int32
+	go func() {
+		mutex.Lock() // writer is waiting for readers
+		writersCount.Add(1)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, mutex.TryRLock()) // new reader doesn't wait for the writer
+
+	mutex.RUnlock()
+	mutex.RUnlock()
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(1), writersCount.Load())
+}
+
+func TestRWMutexFIFO(t *testing.T) {
+	mutex := NewRWMutex(FIFO, 0)
+	mutex.Lock()
+
+	var order []string
+	var orderMutex sync.Mutex
+	record := func(name string) {
+		orderMutex.Lock()
+		defer orderMutex.Unlock()
+		order = append(order, name)
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(3)
+
+	go func() {
+		defer wg.Done()
+		mutex.Lock()
+		record("writer_1")
+		mutex.Unlock()
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	go func() {
+		defer wg.Done()
+		mutex.RLock()
+		record("reader")
+		time.Sleep(50 * time.Millisecond)
+		mutex.RUnlock()
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	go func() {
+		defer wg.Done()
+		mutex.Lock()
+		record("writer_2")
+		mutex.Unlock()
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	mutex.Unlock()
+	wg.Wait()
+
+	// writer preferring mutex would let writer_2 in before the reader
+	assert.Equal(t, []string{"writer_1", "reader", "writer_2"}, order)
+}
+
+func TestRWMutexStarvationMode(t *testing.T) {
+	mutex := NewRWMutex(ReaderPreferring, 50*time.Millisecond)
+	mutex.RLock()
+
+	var writersCount atomic.Int32
+	go func() {
+		mutex.Lock()
+		writersCount.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		mutex.Unlock()
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	assert.True(t, mutex.TryRLock())
+	mutex.RUnlock()
+
+	time.Sleep(100 * time.Millisecond) // the writer is starving
+	assert.False(t, mutex.TryRLock())
+
+	mutex.RUnlock()
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int32(1), writersCount.Load())
+
+	time.Sleep(100 * time.Millisecond) // queue is empty, back to normal mode
+	assert.True(t, mutex.TryRLock())
+	assert.False(t, mutex.starving)
+}
+
+// reproduces lessons/sync_primitives/starvation: greedy readers and the writer
+// run together for the window, writes/window shows whether the writer is starved
+func BenchmarkRWMutexStarvation(b *testing.B) {
+	// readers stop after the window, so the writer finishes
+	// even if readers overlap and starve it forever
+	const window = 1 * time.Second
+	const readers = 4 // enough to overlap all the time
+
+	policies := []struct {
+		name  string
+		mutex *RWMutex
+	}{
+		{"writer_preferring", NewRWMutex(WriterPreferring, 0)},
+		{"reader_preferring", NewRWMutex(ReaderPreferring, 0)},
+		{"reader_preferring_with_guard", NewRWMutex(ReaderPreferring, time.Millisecond)},
+		{"fifo", NewRWMutex(FIFO, 0)},
+	}
+
+	for _, policy := range policies {
+		b.Run(policy.name, func(b *testing.B) {
+			mutex := policy.mutex
+			var reads atomic.Int64
+			var writes int64
+
+			for i := 0; i < b.N; i++ {
+				wg := sync.WaitGroup{}
+				wg.Add(readers + 1)
+				begin := time.Now()
+
+				for j := 0; j < readers; j++ {
+					go func() {
+						defer wg.Done()
+						for time.Since(begin) <= window {
+							mutex.RLock()
+							time.Sleep(3 * time.Nanosecond)
+							mutex.RUnlock()
+							reads.Add(1)
+						}
+					}()
+				}
+
+				go func() {
+					defer wg.Done()
+					for time.Since(begin) <= window {
+						mutex.Lock()
+						time.Sleep(time.Nanosecond)
+						mutex.Unlock()
+
+						if time.Since(begin) <= window { // not after readers are gone
+							writes++
+						}
+					}
+				}()
+
+				wg.Wait()
+			}
+
+			b.ReportMetric(float64(reads.Load())/float64(b.N), "reads/window")
+			b.ReportMetric(float64(writes)/float64(b.N), "writes/window")
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrClosed = errors.New("queue is closed")
+
+// BlockingQueue is a bounded multi-producer multi-consumer queue,
+// unlike a channel it can be peeked, drained and closed by many writers
+type BlockingQueue[T any] struct {
+	mutex    sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+
+	values []T
+	head   int
+	size   int
+	closed bool
+}
+
+func NewBlockingQueue[T any](capacity int) *BlockingQueue[T] {
+	if capacity <= 0 {
+		panic("blocking_queue: capacity must be positive")
+	}
+
+	q := &BlockingQueue[T]{values: make([]T, capacity)}
+	q.notEmpty = sync.NewCond(&q.mutex)
+	q.notFull = sync.NewCond(&q.mutex)
+	return q
+}
+
+func (q *BlockingQueue[T]) push(value T) {
+	q.values[(q.head+q.size)%len(q.values)] = value
+	q.size++
+	q.notEmpty.Signal()
+}
+
+func (q *BlockingQueue[T]) pop() T {
+	var zero T
+	value := q.values[q.head]
+	q.values[q.head] = zero
+	q.head = (q.head + 1) % len(q.values)
+	q.size--
+	q.notFull.Signal()
+	return value
+}
+
+func (q *BlockingQueue[T]) full() bool {
+	return q.size == len(q.values)
+}
+
+func (q *BlockingQueue[T]) empty() bool {
+	return q.size == 0
+}
+
+// wait blocks until the queue is closed or blocked returns false,
+// mutex must be held, sync.Cond knows nothing about contexts,
+// so cancellation wakes up all waiters via AfterFunc
+func (q *BlockingQueue[T]) wait(ctx context.Context, cond *sync.Cond, blocked func() bool) error {
+	if !blocked() || q.closed {
+		return nil
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		q.mutex.Lock()
+		defer q.mutex.Unlock()
+		cond.Broadcast()
+	})
+	defer stop()
+
+	for blocked() && !q.closed {
+		if err := ctx.Err(); err != nil {
+			cond.Signal() // the signal could be addressed to us, pass it on
+			return err
+		}
+
+		cond.Wait()
+	}
+
+	return nil
+}
+
+// Put blocks while the queue is full
+func (q *BlockingQueue[T]) Put(ctx context.Context, value T) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if err := q.wait(ctx, q.notFull, q.full); err != nil {
+		return err
+	}
+	if q.closed {
+		return ErrClosed
+	}
+
+	q.push(value)
+	return nil
+}
+
+// Take blocks while the queue is empty, values put before
+// Close can be taken, after that ErrClosed is returned
+func (q *BlockingQueue[T]) Take(ctx context.Context) (T, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	var zero T
+	if err := q.wait(ctx, q.notEmpty, q.empty); err != nil {
+		return zero, err
+	}
+	if q.empty() {
+		return zero, ErrClosed
+	}
+
+	return q.pop(), nil
+}
+
+// Offer puts the value only if it doesn't need to wait
+func (q *BlockingQueue[T]) Offer(value T) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed || q.full() {
+		return false
+	}
+
+	q.push(value)
+	return true
+}
+
+// Poll takes a value only if it doesn't need to wait
+func (q *BlockingQueue[T]) Poll() (T, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.empty() {
+		var zero T
+		return zero, false
+	}
+
+	return q.pop(), true
+}
+
+func (q *BlockingQueue[T]) Peek() (T, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.empty() {
+		var zero T
+		return zero, false
+	}
+
+	return q.values[q.head], true
+}
+
+// Drain moves available values to dst without waiting
+// and returns the number of moved values
+func (q *BlockingQueue[T]) Drain(dst []T) int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	n := min(len(dst), q.size)
+	for idx := 0; idx < n; idx++ {
+		dst[idx] = q.pop()
+	}
+
+	return n
+}
+
+func (q *BlockingQueue[T]) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.size
+}
+
+// Close can be called many times from any goroutine
+func (q *BlockingQueue[T]) Close() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.closed = true
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+}
+
+func main() {
+	queue := NewBlockingQueue[int](2)
+	ctx := context.Background()
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 5; i++ {
+			_ = queue.Put(ctx, i)
+		}
+
+		queue.Close()
+		queue.Close() // no panic on second close
+		fmt.Println(queue.Put(ctx, 5))
+	}()
+
+	go func() {
+		defer wg.Done()
+		for {
+			value, err := queue.Take(ctx)
+			if err != nil {
+				return
+			}
+
+			fmt.Println(value)
+		}
+	}()
+
+	wg.Wait()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -race -v .
+
+func TestBlockingQueueNonBlocking(t *testing.T) {
+	queue := NewBlockingQueue[int](2)
+
+	_, found := queue.Peek()
+	assert.False(t, found)
+	_, found = queue.Poll()
+	assert.False(t, found)
+
+	assert.True(t, queue.Offer(1))
+	assert.True(t, queue.Offer(2))
+	assert.False(t, queue.Offer(3))
+	assert.Equal(t, 2, queue.Len())
+
+	value, _ := queue.Peek()
+	assert.Equal(t, 1, value)
+	value, _ = queue.Poll()
+	assert.Equal(t, 1, value)
+
+	assert.True(t, queue.Offer(3))
+	dst := make([]int, 5)
+	assert.Equal(t, 2, queue.Drain(dst))
+	assert.Equal(t, []int{2, 3}, dst[:2])
+	assert.Zero(t, queue.Len())
+
+	assert.Panics(t, func() { NewBlockingQueue[int](0) })
+}
+
+func TestBlockingQueueCancellation(t *testing.T) {
+	queue := NewBlockingQueue[int](1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := queue.Take(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	assert.NoError(t, queue.Put(context.Background(), 1))
+	err = queue.Put(ctx, 2)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, queue.Len())
+}
+
+func TestBlockingQueueClose(t *testing.T) {
+	queue := NewBlockingQueue[int](2)
+	ctx := context.Background()
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		queue.Close()
+	}()
+
+	_, err := queue.Take(ctx) // waiter is woken up by Close
+	assert.ErrorIs(t, err, ErrClosed)
+
+	queue = NewBlockingQueue[int](2)
+	assert.NoError(t, queue.Put(ctx, 1))
+	queue.Close()
+	queue.Close()
+
+	assert.ErrorIs(t, queue.Put(ctx, 2), ErrClosed)
+	assert.False(t, queue.Offer(2))
+
+	value, err := queue.Take(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, value)
+	_, err = queue.Take(ctx)
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestBlockingQueueConcurrentAccess(t *testing.T) {
+	const producers = 4
+	const consumers = 4
+	const values = 1000
+
+	queue := NewBlockingQueue[int](8)
+	ctx := context.Background()
+
+	producersWg := sync.WaitGroup{}
+	producersWg.Add(producers)
+	for i := 0; i < producers; i++ {
+		go func() {
+			defer producersWg.Done()
+			for value := 1; value <= values; value++ {
+				assert.NoError(t, queue.Put(ctx, value))
+			}
+		}()
+	}
+
+	sums := make([]int, consumers)
+	consumersWg := sync.WaitGroup{}
+	consumersWg.Add(consumers)
+	for i := 0; i < consumers; i++ {
+		go func() {
+			defer consumersWg.Done()
+			for {
+				value, err := queue.Take(ctx)
+				if err != nil {
+					return
+				}
+				sums[i] += value
+			}
+		}()
+	}
+
+	producersWg.Wait()
+	queue.Close()
+	consumersWg.Wait()
+
+	var sum int
+	for _, value := range sums {
+		sum += value
+	}
+
+	assert.Equal(t, producers*values*(values+1)/2, sum)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -race -v .
+
+func TestBrokerTopics(t *testing.T) {
+	broker := NewBroker[int]()
+	defer broker.Close()
+	ctx := context.Background()
+
+	first, _ := broker.Subscribe("first", 10, Drop)
+	second, _ := broker.Subscribe("second", 10, Drop)
+	both, _ := broker.Subscribe("first", 10, Drop)
+
+	assert.NoError(t, broker.Publish(ctx, "first", 1))
+	assert.NoError(t, broker.Publish(ctx, "second", 2))
+	assert.NoError(t, broker.Publish(ctx, "first", 3))
+	assert.NoError(t, broker.Publish(ctx, "unknown", 4))
+
+	assert.Equal(t, 1, <-first.C())
+	assert.Equal(t, 3, <-first.C())
+	assert.Equal(t, 2, <-second.C())
+	assert.Equal(t, 1, <-both.C())
+	assert.Len(t, both.C(), 1)
+
+	both.Unsubscribe()
+	both.Unsubscribe()
+	_, opened := <-both.C() // the buffered message is still received
+	assert.True(t, opened)
+	_, opened = <-both.C()
+	assert.False(t, opened)
+
+	assert.NoError(t, broker.Publish(ctx, "first", 5))
+	assert.Equal(t, 5, <-first.C())
+}
+
+func TestBrokerDropPolicy(t *testing.T) {
+	broker := NewBroker[int]()
+	defer broker.Close()
+
+	subscription, _ := broker.Subscribe("topic", 2, Drop)
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, broker.Publish(context.Background(), "topic", i))
+	}
+
+	assert.Equal(t, int64(3), subscription.Dropped())
+	assert.Equal(t, 0, <-subscription.C())
+	assert.Equal(t, 1, <-subscription.C())
+}
+
+func TestBrokerDisconnectPolicy(t *testing.T) {
+	broker := NewBroker[int]()
+	defer broker.Close()
+
+	slow, _ := broker.Subscribe("topic", 1, Disconnect)
+	fast, _ := broker.Subscribe("topic", 10, Drop)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, broker.Publish(context.Background(), "topic", i))
+	}
+
+	var received []int
+	for message := range slow.C() {
+		received = append(received, message)
+	}
+
+	assert.Equal(t, []int{0}, received)
+	assert.Len(t, fast.C(), 3)
+}
+
+func TestBrokerBlockPolicy(t *testing.T) {
+	broker := NewBroker[int]()
+	defer broker.Close()
+
+	subscription, _ := broker.Subscribe("topic", 1, Block)
+	assert.NoError(t, broker.Publish(context.Background(), "topic", 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, broker.Publish(ctx, "topic", 2), context.DeadlineExceeded)
+
+	// unsubscribing releases the blocked publisher
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		subscription.Unsubscribe()
+	}()
+
+	assert.NoError(t, broker.Publish(context.Background(), "topic", 3))
+}
+
+func TestBrokerBlockPolicyDeliversToAll(t *testing.T) {
+	broker := NewBroker[int]()
+	defer broker.Close()
+
+	full, _ := broker.Subscribe("topic", 1, Block)
+	assert.NoError(t, broker.Publish(context.Background(), "topic", 1))
+	empty, _ := broker.Subscribe("topic", 1, Block)
+	dropping, _ := broker.Subscribe("topic", 1, Drop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := broker.Publish(ctx, "topic", 2)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, `topic "topic"`)
+
+	assert.Equal(t, 2, <-empty.C())
+	assert.Equal(t, 2, <-dropping.C())
+	assert.Equal(t, 1, <-full.C())
+	assert.Empty(t, full.C())
+}
+
+func TestBrokerClose(t *testing.T) {
+	goroutines := runtime.NumGoroutine()
+	broker := NewBroker[int]()
+
+	wg := sync.WaitGroup{}
+	wg.Add(10)
+	for i := 0; i < 10; i++ {
+		subscription, _ := broker.Subscribe("topic", 0, Block)
+		go func() {
+			defer wg.Done()
+			for range subscription.C() {
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, broker.Publish(context.Background(), "topic", i))
+	}
+
+	broker.Close()
+	wg.Wait()
+
+	assert.ErrorIs(t, broker.Publish(context.Background(), "topic", 0), ErrClosed)
+	_, err := broker.Subscribe("topic", 0, Block)
+	assert.ErrorIs(t, err, ErrClosed)
+	assert.LessOrEqual(t, runtime.NumGoroutine(), goroutines)
+}
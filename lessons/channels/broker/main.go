@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+var ErrClosed = errors.New("broker is closed")
+
+// Policy defines what to do when the buffer of a subscriber is full
+type Policy int
+
+const (
+	Drop       Policy = iota // the message is dropped for this subscriber
+	Block                    // the publisher waits until the subscriber reads or ctx is done
+	Disconnect               // the subscriber is unsubscribed
+)
+
+type Subscription[T any] struct {
+	broker  *Broker[T]
+	topic   string
+	policy  Policy
+	dropped atomic.Int64
+
+	// publishers hold the read lock while sending,
+	// so the channel is never closed during sending
+	mutex    sync.RWMutex
+	messages chan T
+	closed   bool
+	done     chan struct{} // wakes up blocked publishers
+	once     sync.Once
+}
+
+// C is closed after Unsubscribe, so reading with range doesn't leak goroutines
+func (s *Subscription[T]) C() <-chan T {
+	return s.messages
+}
+
+// Dropped returns the number of messages dropped because of the full buffer
+func (s *Subscription[T]) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+func (s *Subscription[T]) Unsubscribe() {
+	s.once.Do(func() {
+		close(s.done)
+		s.broker.remove(s)
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		s.closed = true
+		close(s.messages)
+	})
+}
+
+// deliver returns false if the subscriber has to be disconnected
+func (s *Subscription[T]) deliver(ctx context.Context, message T) (bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.closed {
+		return true, nil
+	}
+
+	if s.policy == Block {
+		select {
+		case s.messages <- message:
+			return true, nil
+		default: // don't lose the message to ctx if there is space
+		}
+
+		select {
+		case s.messages <- message:
+		case <-s.done:
+		case <-ctx.Done():
+			return true, ctx.Err()
+		}
+
+		return true, nil
+	}
+
+	select {
+	case s.messages <- message:
+		return true, nil
+	default:
+		s.dropped.Add(1)
+		return s.policy != Disconnect, nil
+	}
+}
+
+type Broker[T any] struct {
+	mutex  sync.RWMutex
+	topics map[string]map[*Subscription[T]]struct{}
+	closed bool
+}
+
+func NewBroker[T any]() *Broker[T] {
+	return &Broker[T]{
+		topics: make(map[string]map[*Subscription[T]]struct{}),
+	}
+}
+
+func (b *Broker[T]) Subscribe(topic string, buffer int, policy Policy) (*Subscription[T], error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	subscription := &Subscription[T]{
+		broker:   b,
+		topic:    topic,
+		policy:   policy,
+		messages: make(chan T, buffer),
+		done:     make(chan struct{}),
+	}
+
+	if b.topics[topic] == nil {
+		b.topics[topic] = make(map[*Subscription[T]]struct{})
+	}
+
+	b.topics[topic][subscription] = struct{}{}
+	return subscription, nil
+}
+
+func (b *Broker[T]) remove(subscription *Subscription[T]) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	subscriptions := b.topics[subscription.topic]
+	delete(subscriptions, subscription)
+	if len(subscriptions) == 0 {
+		delete(b.topics, subscription.topic)
+	}
+}
+
+// Publish sends the message to all subscribers of the topic even if some of them
+// fail, errors are joined, the broker isn't locked during sending,
+// so slow subscribers don't block others changes
+func (b *Broker[T]) Publish(ctx context.Context, topic string, message T) error {
+	b.mutex.RLock()
+	if b.closed {
+		b.mutex.RUnlock()
+		return ErrClosed
+	}
+
+	subscriptions := make([]*Subscription[T], 0, len(b.topics[topic]))
+	for subscription := range b.topics[topic] {
+		subscriptions = append(subscriptions, subscription)
+	}
+	b.mutex.RUnlock()
+
+	var errs []error
+	for _, subscription := range subscriptions {
+		connected, err := subscription.deliver(ctx, message)
+		if !connected {
+			subscription.Unsubscribe()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("topic %q: %w", subscription.topic, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Close unsubscribes everyone, blocked publishers are released
+func (b *Broker[T]) Close() {
+	b.mutex.Lock()
+	b.closed = true
+	topics := b.topics
+	b.topics = make(map[string]map[*Subscription[T]]struct{})
+	b.mutex.Unlock()
+
+	for _, subscriptions := range topics {
+		for subscription := range subscriptions {
+			subscription.Unsubscribe()
+		}
+	}
+}
+
+func main() {
+	broker := NewBroker[string]()
+	ctx := context.Background()
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	for i := 0; i < 2; i++ {
+		subscription, _ := broker.Subscribe("news", 10, Block)
+		go func() {
+			defer wg.Done()
+			for message := range subscription.C() {
+				fmt.Printf("[subscriber_%d] %s\n", i, message)
+			}
+		}()
+	}
+
+	for i := 0; i < 3; i++ {
+		_ = broker.Publish(ctx, "news", fmt.Sprint("message_", i))
+	}
+
+	broker.Close()
+	wg.Wait()
+}
@@ -0,0 +1,254 @@
+// Package combinators contains channel plumbing used across the channels lessons,
+// every combinator stops and closes its outputs when ctx is done,
+// so abandoned pipelines don't leak goroutines
+package combinators
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+func send[T any](ctx context.Context, out chan<- T, value T) bool {
+	select {
+	case out <- value:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// pipe forwards values until in is closed or ctx is done
+func pipe[T any](ctx context.Context, in <-chan T, out chan<- T) bool {
+	for {
+		select {
+		case value, ok := <-in:
+			if !ok {
+				return true
+			}
+			if !send(ctx, out, value) {
+				return false
+			}
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// OrDone allows to range over in without checking ctx in every iteration
+func OrDone[T any](ctx context.Context, in <-chan T) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		pipe(ctx, in, out)
+	}()
+
+	return out
+}
+
+// Merge sends values from all channels into one, the order between channels isn't kept
+func Merge[T any](ctx context.Context, chans ...<-chan T) <-chan T {
+	out := make(chan T)
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(chans))
+	for _, in := range chans {
+		go func() {
+			defer wg.Done()
+			pipe(ctx, in, out)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// Tee sends every value to both outputs, the next value
+// is read only after both outputs received the previous one
+func Tee[T any](ctx context.Context, in <-chan T) (<-chan T, <-chan T) {
+	out1 := make(chan T)
+	out2 := make(chan T)
+	go func() {
+		defer close(out1)
+		defer close(out2)
+
+		for value := range OrDone(ctx, in) {
+			first, second := out1, out2
+			for first != nil || second != nil {
+				select {
+				case first <- value:
+					first = nil
+				case second <- value:
+					second = nil
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out1, out2
+}
+
+// FanOut distributes values between n outputs, every value
+// is received by the first ready output only
+func FanOut[T any](ctx context.Context, in <-chan T, n int) []<-chan T {
+	outs := make([]<-chan T, n)
+	for i := range outs {
+		out := make(chan T)
+		outs[i] = out
+
+		go func() {
+			defer close(out)
+			pipe(ctx, in, out)
+		}()
+	}
+
+	return outs
+}
+
+// Bridge flattens a channel of channels, values are sent in the order of channels
+func Bridge[T any](ctx context.Context, chans <-chan <-chan T) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+
+		for in := range OrDone(ctx, chans) {
+			if !pipe(ctx, in, out) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Batch groups values into slices of size, an incomplete batch is sent
+// when maxWait passed since its first value or when in is closed
+func Batch[T any](ctx context.Context, in <-chan T, size int, maxWait time.Duration) <-chan []T {
+	if size <= 0 {
+		panic("combinators: batch size must be positive")
+	}
+
+	out := make(chan []T)
+	go func() {
+		defer close(out)
+
+		timer := time.NewTimer(maxWait)
+		defer timer.Stop()
+		timer.Stop()
+
+		var batch []T
+		var timeout <-chan time.Time // nil while the batch is empty
+		flush := func() bool {
+			timer.Stop()
+			timeout = nil
+			if len(batch) == 0 {
+				return true
+			}
+
+			full := batch
+			batch = make([]T, 0, size)
+			return send(ctx, out, full)
+		}
+
+		for {
+			select {
+			case value, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+
+				batch = append(batch, value)
+				if len(batch) == 1 {
+					timer.Reset(maxWait)
+					timeout = timer.C
+				}
+				if len(batch) == size && !flush() {
+					return
+				}
+			case <-timeout:
+				if !flush() {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Throttle passes at most one value per interval, values aren't dropped
+// but delayed, so a fast producer is slowed down
+func Throttle[T any](ctx context.Context, in <-chan T, interval time.Duration) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+
+		for value := range OrDone(ctx, in) {
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				return
+			}
+
+			if !send(ctx, out, value) {
+				return
+			}
+
+			timer.Reset(interval)
+		}
+	}()
+
+	return out
+}
+
+// Debounce sends the last value only after quiet passed without new values,
+// the pending value is sent when in is closed
+func Debounce[T any](ctx context.Context, in <-chan T, quiet time.Duration) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+
+		timer := time.NewTimer(quiet)
+		defer timer.Stop()
+		timer.Stop()
+
+		var last T
+		var fire <-chan time.Time // nil while there is no pending value
+		for {
+			select {
+			case value, ok := <-in:
+				if !ok {
+					if fire != nil {
+						send(ctx, out, last)
+					}
+					return
+				}
+
+				last = value
+				timer.Reset(quiet)
+				fire = timer.C
+			case <-fire:
+				fire = nil
+				if !send(ctx, out, last) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
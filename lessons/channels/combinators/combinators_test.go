@@ -0,0 +1,181 @@
+package combinators
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -race -v .
+
+func generate(values ...int) <-chan int {
+	out := make(chan int, len(values))
+	for _, value := range values {
+		out <- value
+	}
+
+	close(out)
+	return out
+}
+
+func collect[T any](in <-chan T) []T {
+	var values []T
+	for value := range in {
+		values = append(values, value)
+	}
+
+	return values
+}
+
+// checkLeaks returns the function that waits until goroutines started by the test finish
+func checkLeaks(t *testing.T) func() {
+	goroutines := runtime.NumGoroutine()
+	return func() {
+		deadline := time.Now().Add(time.Second)
+		for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		assert.LessOrEqual(t, runtime.NumGoroutine(), goroutines)
+	}
+}
+
+func TestOrDone(t *testing.T) {
+	defer checkLeaks(t)()
+
+	assert.Equal(t, []int{1, 2, 3}, collect(OrDone(context.Background(), generate(1, 2, 3))))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	never := make(chan int)
+	out := OrDone(ctx, never)
+	cancel()
+	assert.Empty(t, collect(out))
+}
+
+func TestMerge(t *testing.T) {
+	defer checkLeaks(t)()
+
+	values := collect(Merge(context.Background(), generate(1, 2), generate(3), generate(4, 5, 6)))
+	slices.Sort(values)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, values)
+
+	assert.Empty(t, collect(Merge[int](context.Background())))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	never := make(chan int)
+	out := Merge(ctx, never, generate(1, 2, 3))
+	<-out
+	cancel()
+	collect(out)
+}
+
+func TestTee(t *testing.T) {
+	defer checkLeaks(t)()
+
+	first, second := Tee(context.Background(), generate(1, 2, 3))
+	done := make(chan []int)
+	go func() {
+		done <- collect(first)
+	}()
+
+	assert.Equal(t, []int{1, 2, 3}, collect(second))
+	assert.Equal(t, []int{1, 2, 3}, <-done)
+
+	// nobody reads the outputs
+	ctx, cancel := context.WithCancel(context.Background())
+	Tee(ctx, generate(1, 2, 3))
+	cancel()
+}
+
+func TestFanOut(t *testing.T) {
+	defer checkLeaks(t)()
+
+	outs := FanOut(context.Background(), generate(1, 2, 3, 4, 5, 6, 7, 8, 9, 10), 3)
+	assert.Len(t, outs, 3)
+
+	values := collect(Merge(context.Background(), outs...))
+	slices.Sort(values)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, values)
+}
+
+func TestBridge(t *testing.T) {
+	defer checkLeaks(t)()
+
+	chans := make(chan (<-chan int))
+	go func() {
+		defer close(chans)
+		chans <- generate(1, 2)
+		chans <- generate()
+		chans <- generate(3, 4)
+	}()
+
+	assert.Equal(t, []int{1, 2, 3, 4}, collect(Bridge(context.Background(), chans)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	never := make(chan (<-chan int))
+	out := Bridge(ctx, never)
+	cancel()
+	assert.Empty(t, collect(out))
+}
+
+func TestBatch(t *testing.T) {
+	defer checkLeaks(t)()
+
+	assert.Panics(t, func() { Batch(context.Background(), generate(), 0, time.Hour) })
+
+	batches := collect(Batch(context.Background(), generate(1, 2, 3, 4, 5), 2, time.Hour))
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, batches)
+
+	in := make(chan int)
+	out := Batch(context.Background(), in, 10, 50*time.Millisecond)
+	in <- 1
+	in <- 2
+
+	start := time.Now()
+	assert.Equal(t, []int{1, 2}, <-out)
+	assert.Less(t, time.Since(start), time.Second)
+
+	in <- 3
+	close(in)
+	assert.Equal(t, []int{3}, <-out)
+	_, opened := <-out
+	assert.False(t, opened)
+}
+
+func TestThrottle(t *testing.T) {
+	defer checkLeaks(t)()
+
+	const interval = 20 * time.Millisecond
+
+	start := time.Now()
+	values := collect(Throttle(context.Background(), generate(1, 2, 3, 4, 5), interval))
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, values)
+	assert.GreaterOrEqual(t, time.Since(start), 4*interval)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := Throttle(ctx, generate(1, 2, 3), time.Hour)
+	assert.Equal(t, 1, <-out)
+	cancel()
+	collect(out)
+}
+
+func TestDebounce(t *testing.T) {
+	defer checkLeaks(t)()
+
+	in := make(chan int)
+	out := Debounce(context.Background(), in, 50*time.Millisecond)
+
+	for i := 1; i <= 5; i++ {
+		in <- i
+	}
+	assert.Equal(t, 5, <-out)
+
+	in <- 6
+	in <- 7
+	close(in)
+	assert.Equal(t, []int{7}, collect(out))
+}
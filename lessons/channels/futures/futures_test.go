@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -race -v .
+
+func after[T any](delay time.Duration, value T, err error) func(context.Context) (T, error) {
+	return func(ctx context.Context) (T, error) {
+		select {
+		case <-time.After(delay):
+			return value, err
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+func TestFuture(t *testing.T) {
+	ctx := context.Background()
+
+	value, err := Async(ctx, after(10*time.Millisecond, 1, nil)).Await(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	failure := errors.New("failure")
+	_, err = Async(ctx, after(0, 1, failure)).Await(ctx)
+	assert.ErrorIs(t, err, failure)
+
+	_, err = Async(ctx, func(context.Context) (int, error) { panic("boom") }).Await(ctx)
+	assert.ErrorContains(t, err, "boom")
+
+	awaitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	future := Async(ctx, after(50*time.Millisecond, 2, nil))
+	_, err = future.Await(awaitCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	value, err = future.Await(ctx) // the producer isn't canceled
+	assert.NoError(t, err)
+	assert.Equal(t, 2, value)
+}
+
+func TestFutureCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	future := Async(ctx, after(time.Hour, 1, nil))
+	cancel()
+	_, err := future.Await(context.Background())
+	assert.ErrorIs(t, err, context.Canceled)
+
+	future = Async(context.Background(), after(time.Hour, 1, nil))
+	_, err = future.AwaitTimeout(10 * time.Millisecond)
+	assert.ErrorIs(t, err, ErrTimeout)
+
+	<-future.Done()
+	_, err = future.Await(context.Background())
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestThen(t *testing.T) {
+	ctx := context.Background()
+
+	future := Then(Async(ctx, after(0, 42, nil)), func(_ context.Context, value int) (string, error) {
+		return strconv.Itoa(value), nil
+	})
+
+	value, err := future.Await(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "42", value)
+
+	failure := errors.New("failure")
+	called := false
+	future = Then(Async(ctx, after(0, 0, failure)), func(context.Context, int) (string, error) {
+		called = true
+		return "", nil
+	})
+
+	_, err = future.Await(ctx)
+	assert.ErrorIs(t, err, failure)
+	assert.False(t, called)
+}
+
+func TestAwaitAll(t *testing.T) {
+	ctx := context.Background()
+
+	start := time.Now()
+	values, err := AwaitAll(ctx,
+		Async(ctx, after(50*time.Millisecond, 1, nil)),
+		Async(ctx, after(50*time.Millisecond, 2, nil)),
+		Async(ctx, after(10*time.Millisecond, 3, nil)),
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, values)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	failure := errors.New("failure")
+	slow := Async(ctx, after(time.Hour, 1, nil))
+	_, err = AwaitAll(ctx, slow, Async(ctx, after(0, 0, failure)))
+	assert.ErrorIs(t, err, failure)
+
+	<-slow.Done()
+	_, err = slow.Await(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestAwaitAny(t *testing.T) {
+	ctx := context.Background()
+
+	failure := errors.New("failure")
+	slow := Async(ctx, after(time.Hour, 1, nil))
+	value, err := AwaitAny(ctx,
+		slow,
+		Async(ctx, after(0, 2, failure)),
+		Async(ctx, after(10*time.Millisecond, 3, nil)),
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, value)
+
+	<-slow.Done()
+	_, err = slow.Await(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	another := errors.New("another")
+	_, err = AwaitAny(ctx, Async(ctx, after(0, 0, failure)), Async(ctx, after(0, 0, another)))
+	assert.ErrorIs(t, err, failure)
+	assert.ErrorIs(t, err, another)
+
+	awaitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = AwaitAny(awaitCtx, Async(ctx, after(time.Hour, 1, nil)))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	value, err = AwaitAny[int](ctx)
+	assert.ErrorIs(t, err, ErrNoFutures)
+	assert.Zero(t, value)
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrTimeout   = errors.New("future timed out")
+	ErrNoFutures = errors.New("no futures to await")
+)
+
+type Future[T any] struct {
+	ctx    context.Context // parent for Then
+	cancel context.CancelFunc
+	done   chan struct{}
+	value  T
+	err    error
+}
+
+// Async runs fn in a new goroutine, ctx passed to fn is canceled when
+// the parent ctx is done, the future is canceled or the result is ready
+func Async[T any](ctx context.Context, fn func(context.Context) (T, error)) *Future[T] {
+	fnCtx, cancel := context.WithCancel(ctx)
+	f := &Future[T]{
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(f.done)
+		defer cancel()
+		defer func() {
+			if r := recover(); r != nil {
+				f.err = fmt.Errorf("future: panic: %v", r)
+			}
+		}()
+
+		f.value, f.err = fn(fnCtx)
+	}()
+
+	return f
+}
+
+// Done is closed when the result is ready
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Cancel cancels ctx of the producer, the result is still
+// ready only after the producer returns
+func (f *Future[T]) Cancel() {
+	f.cancel()
+}
+
+// Await returns ctx.Err() if ctx is done before the result,
+// the producer isn't canceled in this case
+func (f *Future[T]) Await(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// AwaitTimeout cancels the producer if the result isn't ready in time
+func (f *Future[T]) AwaitTimeout(timeout time.Duration) (T, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-timer.C:
+		f.cancel()
+		var zero T
+		return zero, ErrTimeout
+	}
+}
+
+// Then runs fn with the result of f, errors of f are passed through
+// without calling fn, canceling the new future doesn't cancel f
+func Then[T, U any](f *Future[T], fn func(context.Context, T) (U, error)) *Future[U] {
+	return Async(f.ctx, func(ctx context.Context) (U, error) {
+		value, err := f.Await(ctx)
+		if err != nil {
+			var zero U
+			return zero, err
+		}
+
+		return fn(ctx, value)
+	})
+}
+
+func cancelAll[T any](futures []*Future[T]) {
+	for _, f := range futures {
+		f.Cancel()
+	}
+}
+
+// AwaitAll returns results in the order of futures, the first error
+// cancels the rest of the futures and is returned
+func AwaitAll[T any](ctx context.Context, futures ...*Future[T]) ([]T, error) {
+	ready := notify(futures)
+	defer close(ready.stop)
+
+	values := make([]T, len(futures))
+	for range futures {
+		select {
+		case i := <-ready.indexes:
+			if futures[i].err != nil {
+				cancelAll(futures)
+				return nil, futures[i].err
+			}
+
+			values[i] = futures[i].value
+		case <-ctx.Done():
+			cancelAll(futures)
+			return nil, ctx.Err()
+		}
+	}
+
+	return values, nil
+}
+
+// AwaitAny returns the first successful result and cancels the rest
+// of the futures, if all of them fail the joined error is returned
+func AwaitAny[T any](ctx context.Context, futures ...*Future[T]) (T, error) {
+	var zero T
+	if len(futures) == 0 {
+		return zero, ErrNoFutures
+	}
+
+	ready := notify(futures)
+	defer close(ready.stop)
+	defer cancelAll(futures)
+
+	errs := make([]error, 0, len(futures))
+	for range futures {
+		select {
+		case i := <-ready.indexes:
+			if futures[i].err == nil {
+				return futures[i].value, nil
+			}
+
+			errs = append(errs, futures[i].err)
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+
+	return zero, errors.Join(errs...)
+}
+
+type readiness struct {
+	indexes chan int
+	stop    chan struct{}
+}
+
+// notify sends indexes of futures in the order they become ready,
+// goroutines exit when stop is closed
+func notify[T any](futures []*Future[T]) readiness {
+	ready := readiness{
+		indexes: make(chan int, len(futures)),
+		stop:    make(chan struct{}),
+	}
+
+	for i, f := range futures {
+		go func() {
+			select {
+			case <-f.done:
+				ready.indexes <- i
+			case <-ready.stop:
+			}
+		}()
+	}
+
+	return ready
+}
+
+func FetchData1(ctx context.Context) (int, error) {
+	select {
+	case <-time.After(time.Second * 2):
+		return 10, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func FetchData2(ctx context.Context) (int, error) {
+	select {
+	case <-time.After(time.Second * 2):
+		return 20, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func Process(value1, value2 int) {
+	// Processing...
+}
+
+func main() {
+	ctx := context.Background()
+
+	start := time.Now()
+	values, _ := AwaitAll(ctx, Async(ctx, FetchData1), Async(ctx, FetchData2))
+	Process(values[0], values[1])
+	fmt.Println(time.Now().Sub(start))
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrClosed = errors.New("channel is closed")
+
+// SafeChan never panics on sending after closing: senders hold the read lock
+// while sending, so the channel is closed only when nobody sends to it,
+// blocked senders are woken up by done before that
+type SafeChan[T any] struct {
+	mutex sync.RWMutex
+	ch    chan T
+	done  chan struct{}
+	once  sync.Once
+}
+
+func NewSafeChan[T any](capacity int) *SafeChan[T] {
+	return &SafeChan[T]{
+		ch:   make(chan T, capacity),
+		done: make(chan struct{}),
+	}
+}
+
+// C is used to receive values, it is closed after Close
+func (c *SafeChan[T]) C() <-chan T {
+	return c.ch
+}
+
+// Close is idempotent, values sent before it can still be received
+func (c *SafeChan[T]) Close() {
+	c.once.Do(func() {
+		close(c.done)
+
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		close(c.ch)
+	})
+}
+
+// IsClosed doesn't consume values, the result can be outdated
+// right after the call if Close is called concurrently
+func (c *SafeChan[T]) IsClosed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// TrySend returns false if the buffer is full or there is no ready receiver
+func (c *SafeChan[T]) TrySend(value T) (bool, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if c.IsClosed() {
+		return false, ErrClosed
+	}
+
+	select {
+	case c.ch <- value:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+func (c *SafeChan[T]) SendContext(ctx context.Context, value T) error {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if c.IsClosed() {
+		return ErrClosed
+	}
+
+	select {
+	case c.ch <- value:
+		return nil
+	case <-c.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *SafeChan[T]) Len() int {
+	return len(c.ch)
+}
+
+func (c *SafeChan[T]) Cap() int {
+	return cap(c.ch)
+}
+
+func main() {
+	ch := NewSafeChan[int](1)
+	_, _ = ch.TrySend(1)
+	fmt.Println(ch.IsClosed(), ch.Len())
+
+	ch.Close()
+	ch.Close()
+	fmt.Println(ch.IsClosed(), ch.Len())
+	fmt.Println(ch.SendContext(context.Background(), 2))
+
+	for value := range ch.C() {
+		fmt.Println(value)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -race -v .
+
+func TestSafeChan(t *testing.T) {
+	ch := NewSafeChan[int](2)
+	assert.Equal(t, 2, ch.Cap())
+	assert.False(t, ch.IsClosed())
+
+	sent, err := ch.TrySend(1)
+	assert.True(t, sent)
+	assert.NoError(t, err)
+	assert.NoError(t, ch.SendContext(context.Background(), 2))
+
+	sent, err = ch.TrySend(3)
+	assert.False(t, sent)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, ch.SendContext(ctx, 3), context.DeadlineExceeded)
+
+	assert.False(t, ch.IsClosed())
+	assert.Equal(t, 2, ch.Len())
+
+	ch.Close()
+	ch.Close()
+	assert.True(t, ch.IsClosed())
+	assert.Equal(t, 2, ch.Len())
+
+	sent, err = ch.TrySend(3)
+	assert.False(t, sent)
+	assert.ErrorIs(t, err, ErrClosed)
+	assert.ErrorIs(t, ch.SendContext(context.Background(), 3), ErrClosed)
+
+	var values []int
+	for value := range ch.C() {
+		values = append(values, value)
+	}
+	assert.Equal(t, []int{1, 2}, values)
+}
+
+func TestSafeChanCloseWakesSenders(t *testing.T) {
+	ch := NewSafeChan[int](0)
+
+	errs := make(chan error, 10)
+	for range 10 {
+		go func() {
+			errs <- ch.SendContext(context.Background(), 1)
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	ch.Close()
+
+	for range 10 {
+		assert.ErrorIs(t, <-errs, ErrClosed)
+	}
+}
+
+func TestSafeChanConcurrentClose(t *testing.T) {
+	ch := NewSafeChan[int](10)
+
+	wg := sync.WaitGroup{}
+	wg.Add(20)
+	for i := range 10 {
+		go func() {
+			defer wg.Done()
+			for {
+				if _, err := ch.TrySend(i); err != nil {
+					return
+				}
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			time.Sleep(time.Millisecond)
+			ch.Close()
+		}()
+	}
+
+	go func() {
+		for range ch.C() {
+		}
+	}()
+
+	wg.Wait()
+	assert.True(t, ch.IsClosed())
+}
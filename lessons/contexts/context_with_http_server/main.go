@@ -4,8 +4,12 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+
+	"golang_course/lessons/contexts/ctxkey"
 )
 
+var traceIDKey = ctxkey.New[string]("trace_id")
+
 func main() {
 	helloWorldHandler := http.HandlerFunc(handle)
 	http.Handle("/welcome", injectTraceID(helloWorldHandler))
@@ -13,7 +17,7 @@ func main() {
 }
 
 func handle(_ http.ResponseWriter, r *http.Request) {
-	value, ok := r.Context().Value("trace_id").(string)
+	value, ok := traceIDKey.Value(r.Context())
 	if ok {
 		fmt.Println(value)
 	}
@@ -27,7 +31,7 @@ func makeRequest(_ context.Context) {
 
 func injectTraceID(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := context.WithValue(r.Context(), "trace_id", "12-21-33")
+		ctx := ctxkey.WithValue(r.Context(), traceIDKey, "12-21-33")
 		req := r.WithContext(ctx)
 		next.ServeHTTP(w, req)
 	})
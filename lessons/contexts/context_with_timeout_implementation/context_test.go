@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -race -v .
+
+type implementation struct {
+	name          string
+	background    func() context.Context
+	withCancel    func(context.Context) (context.Context, context.CancelFunc)
+	withDeadline  func(context.Context, time.Time) (context.Context, context.CancelFunc)
+	withTimeout   func(context.Context, time.Duration) (context.Context, context.CancelFunc)
+	withValue     func(context.Context, any, any) context.Context
+	withoutCancel func(context.Context) context.Context
+	afterFunc     func(context.Context, func()) func() bool
+}
+
+// the conformance suite runs the same checks against both implementations
+var implementations = []implementation{
+	{
+		name:          "std",
+		background:    context.Background,
+		withCancel:    context.WithCancel,
+		withDeadline:  context.WithDeadline,
+		withTimeout:   context.WithTimeout,
+		withValue:     context.WithValue,
+		withoutCancel: context.WithoutCancel,
+		afterFunc:     context.AfterFunc,
+	},
+	{
+		name:          "ours",
+		background:    Background,
+		withCancel:    WithCancel,
+		withDeadline:  WithDeadline,
+		withTimeout:   WithTimeout,
+		withValue:     WithValue,
+		withoutCancel: WithoutCancel,
+		afterFunc:     AfterFunc,
+	},
+}
+
+func conformance(t *testing.T, test func(*testing.T, implementation)) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			test(t, impl)
+		})
+	}
+}
+
+func isDone(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+type key string
+
+func TestBackground(t *testing.T) {
+	conformance(t, func(t *testing.T, impl implementation) {
+		ctx := impl.background()
+		_, ok := ctx.Deadline()
+		assert.False(t, ok)
+		assert.Nil(t, ctx.Done())
+		assert.NoError(t, ctx.Err())
+		assert.Nil(t, ctx.Value(key("key")))
+	})
+}
+
+func TestCancel(t *testing.T) {
+	conformance(t, func(t *testing.T, impl implementation) {
+		parent, cancelParent := impl.withCancel(impl.background())
+		child, cancelChild := impl.withCancel(parent)
+		grandchild, cancelGrandchild := impl.withCancel(child)
+		defer cancelGrandchild()
+
+		sibling, cancelSibling := impl.withCancel(parent)
+		cancelSibling()
+		cancelSibling()
+		assert.ErrorIs(t, sibling.Err(), context.Canceled)
+		assert.False(t, isDone(parent))
+
+		cancelParent()
+		assert.True(t, isDone(parent))
+		assert.True(t, isDone(child))
+		assert.True(t, isDone(grandchild))
+		assert.Equal(t, context.Canceled, grandchild.Err())
+
+		cancelChild()
+		assert.Equal(t, context.Canceled, child.Err())
+
+		late, cancelLate := impl.withCancel(parent)
+		defer cancelLate()
+		assert.True(t, isDone(late))
+	})
+}
+
+func TestDeadline(t *testing.T) {
+	conformance(t, func(t *testing.T, impl implementation) {
+		ctx, cancel := impl.withTimeout(impl.background(), 20*time.Millisecond)
+		defer cancel()
+
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(20*time.Millisecond), deadline, 10*time.Millisecond)
+
+		later, cancelLater := impl.withTimeout(ctx, time.Hour)
+		defer cancelLater()
+		laterDeadline, _ := later.Deadline()
+		assert.Equal(t, deadline, laterDeadline)
+
+		<-later.Done()
+		assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+		assert.Equal(t, context.DeadlineExceeded, later.Err())
+
+		past, cancelPast := impl.withDeadline(impl.background(), time.Now().Add(-time.Second))
+		assert.True(t, isDone(past))
+		assert.Equal(t, context.DeadlineExceeded, past.Err())
+		cancelPast()
+		assert.Equal(t, context.DeadlineExceeded, past.Err())
+
+		canceled, cancelEarly := impl.withTimeout(impl.background(), time.Hour)
+		cancelEarly()
+		assert.Equal(t, context.Canceled, canceled.Err())
+	})
+}
+
+func TestValue(t *testing.T) {
+	conformance(t, func(t *testing.T, impl implementation) {
+		ctx := impl.withValue(impl.background(), key("a"), 1)
+		ctx = impl.withValue(ctx, key("b"), 2)
+		ctx, cancel := impl.withCancel(ctx)
+		defer cancel()
+		ctx, cancelTimeout := impl.withTimeout(ctx, time.Hour)
+		defer cancelTimeout()
+		ctx = impl.withValue(ctx, key("a"), 3)
+
+		assert.Equal(t, 3, ctx.Value(key("a")))
+		assert.Equal(t, 2, ctx.Value(key("b")))
+		assert.Nil(t, ctx.Value(key("c")))
+		assert.Nil(t, ctx.Value("a")) // keys of different types are different
+
+		assert.Panics(t, func() { impl.withValue(ctx, nil, 1) })
+		assert.Panics(t, func() { impl.withValue(ctx, []int{}, 1) })
+		assert.Panics(t, func() { impl.withCancel(nil) })
+	})
+}
+
+func TestWithoutCancel(t *testing.T) {
+	conformance(t, func(t *testing.T, impl implementation) {
+		parent, cancel := impl.withTimeout(impl.withValue(impl.background(), key("a"), 1), time.Hour)
+		ctx := impl.withoutCancel(parent)
+		child, cancelChild := impl.withCancel(ctx)
+		defer cancelChild()
+
+		cancel()
+		assert.Nil(t, ctx.Done())
+		assert.NoError(t, ctx.Err())
+		_, ok := ctx.Deadline()
+		assert.False(t, ok)
+		assert.Equal(t, 1, ctx.Value(key("a")))
+		assert.False(t, isDone(child))
+	})
+}
+
+func TestAfterFunc(t *testing.T) {
+	conformance(t, func(t *testing.T, impl implementation) {
+		ctx, cancel := impl.withCancel(impl.background())
+
+		called := make(chan struct{})
+		impl.afterFunc(ctx, func() { close(called) })
+
+		var stoppedCalls atomic.Int32
+		stop := impl.afterFunc(ctx, func() { stoppedCalls.Add(1) })
+		assert.True(t, stop())
+		assert.False(t, stop())
+
+		cancel()
+		<-called
+		assert.Zero(t, stoppedCalls.Load())
+
+		again := make(chan struct{})
+		stop = impl.afterFunc(ctx, func() { close(again) })
+		<-again
+		assert.False(t, stop())
+	})
+}
+
+func TestMixedParents(t *testing.T) {
+	stdParent, cancelStd := context.WithCancel(context.Background())
+	ours, cancelOurs := WithTimeout(stdParent, time.Hour)
+	defer cancelOurs()
+	std, cancelStdChild := context.WithCancel(context.WithValue(ours, key("a"), 1))
+	defer cancelStdChild()
+	oursAgain, cancelOursAgain := WithCancel(std)
+	defer cancelOursAgain()
+
+	cancelStd()
+	<-oursAgain.Done()
+	assert.Equal(t, context.Canceled, ours.Err())
+	assert.Equal(t, context.Canceled, std.Err())
+	assert.Equal(t, context.Canceled, oursAgain.Err())
+	assert.Equal(t, 1, oursAgain.Value(key("a")))
+}
+
+func TestChildrenWithoutGoroutines(t *testing.T) {
+	const children = 1000
+
+	parent, cancel := WithCancel(Background())
+	wrapped := WithValue(parent, key("a"), 1)
+	goroutines := runtime.NumGoroutine()
+
+	cancels := make([]context.CancelFunc, 0, 3*children)
+	for range children {
+		_, cancelChild := WithCancel(parent)
+		_, cancelTimeout := WithTimeout(wrapped, time.Hour)
+		stop := AfterFunc(wrapped, func() {})
+		cancels = append(cancels, cancelChild, cancelTimeout, func() { stop() })
+	}
+
+	assert.LessOrEqual(t, runtime.NumGoroutine(), goroutines)
+
+	for _, cancelChild := range cancels[:len(cancels)/2] {
+		cancelChild()
+	}
+
+	c := parent.(*cancelCtx)
+	c.mutex.Lock()
+	assert.Len(t, c.children, len(cancels)/2)
+	c.mutex.Unlock()
+
+	cancel()
+	c.mutex.Lock()
+	assert.Empty(t, c.children)
+	c.mutex.Unlock()
+}
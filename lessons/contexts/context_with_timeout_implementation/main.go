@@ -1,72 +1,336 @@
 package main
 
 import (
-	"errors"
+	"context"
 	"fmt"
-	"sync/atomic"
+	"reflect"
+	"sync"
 	"time"
 )
 
-type Context struct {
-	done   chan struct{}
-	closed int32
+// Errors are shared with the standard library,
+// so errors.Is works the same way for both implementations
+var (
+	Canceled         = context.Canceled
+	DeadlineExceeded = context.DeadlineExceeded
+)
+
+type emptyCtx struct{}
+
+func (emptyCtx) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (emptyCtx) Done() <-chan struct{}       { return nil }
+func (emptyCtx) Err() error                  { return nil }
+func (emptyCtx) Value(any) any               { return nil }
+
+func Background() context.Context {
+	return emptyCtx{}
 }
 
-func WithTimeout(parent Context, duration time.Duration) (*Context, func()) {
-	if atomic.LoadInt32(&parent.closed) == 1 {
-		return nil, nil // don't use nil
+// canceler is a child registered in the parent cancelCtx
+type canceler interface {
+	cancel(removeFromParent bool, err error)
+}
+
+// cancelCtxKey is used to find the closest cancelCtx through Value,
+// so children of wrapped contexts are registered without goroutines
+var cancelCtxKey int
+
+type cancelCtx struct {
+	context.Context // parent
+
+	done     chan struct{}
+	mutex    sync.Mutex
+	err      error
+	children map[canceler]struct{}
+	detach   func() bool // stops watching a foreign parent
+}
+
+func newCancelCtx(parent context.Context) *cancelCtx {
+	if parent == nil {
+		panic("cannot create context from nil parent")
 	}
 
-	ctx := &Context{
-		done: make(chan struct{}),
+	return &cancelCtx{
+		Context: parent,
+		done:    make(chan struct{}),
 	}
+}
 
-	cancel := func() {
-		if atomic.CompareAndSwapInt32(&ctx.closed, 0, 1) {
-			close(ctx.done)
-		}
+func (c *cancelCtx) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *cancelCtx) Err() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.err
+}
+
+func (c *cancelCtx) Value(key any) any {
+	if key == &cancelCtxKey {
+		return c
 	}
 
-	go func() {
-		timer := time.NewTimer(duration)
-		defer timer.Stop()
+	return c.Context.Value(key)
+}
 
-		select {
-		case <-parent.Done():
-		case <-timer.C:
+func (c *cancelCtx) cancel(removeFromParent bool, err error) {
+	c.mutex.Lock()
+	if c.err != nil {
+		c.mutex.Unlock()
+		return
+	}
+
+	c.err = err
+	close(c.done)
+	children := c.children
+	c.children = nil
+	c.mutex.Unlock()
+
+	for child := range children {
+		child.cancel(false, err)
+	}
+
+	if removeFromParent {
+		removeChild(c.Context, c)
+		if c.detach != nil {
+			c.detach()
 		}
+	}
+}
 
-		cancel()
-	}()
+// parentCancelCtx returns the closest cancelCtx if parent isn't done
+// and its Done isn't overridden by a foreign wrapper
+func parentCancelCtx(parent context.Context) (*cancelCtx, bool) {
+	done := parent.Done()
+	if done == nil {
+		return nil, false
+	}
+
+	p, ok := parent.Value(&cancelCtxKey).(*cancelCtx)
+	if !ok || p.done != done {
+		return nil, false
+	}
 
-	return ctx, cancel
+	return p, true
 }
 
-func (c *Context) Done() <-chan struct{} {
-	return c.done
+// addChild returns false if the parent is already canceled
+func (c *cancelCtx) addChild(child canceler) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.err != nil {
+		return false
+	}
+
+	if c.children == nil {
+		c.children = make(map[canceler]struct{})
+	}
+
+	c.children[child] = struct{}{}
+	return true
+}
+
+func removeChild(parent context.Context, child canceler) {
+	p, ok := parentCancelCtx(parent)
+	if !ok {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.children, child)
 }
 
-func (c *Context) Err() error {
+// propagateCancel cancels child when parent is done
+func (c *cancelCtx) propagateCancel(parent context.Context, child canceler) {
+	done := parent.Done()
+	if done == nil {
+		return // parent is never canceled
+	}
+
 	select {
-	case <-c.done:
-		return errors.New("context deadline exceeded")
+	case <-done:
+		child.cancel(false, parent.Err())
+		return
 	default:
-		return nil
 	}
+
+	if p, ok := parentCancelCtx(parent); ok {
+		if !p.addChild(child) {
+			child.cancel(false, p.Err())
+		}
+		return
+	}
+
+	// context.AfterFunc doesn't start goroutines for contexts of the standard library
+	c.detach = context.AfterFunc(parent, func() {
+		child.cancel(false, parent.Err())
+	})
+}
+
+func WithCancel(parent context.Context) (context.Context, context.CancelFunc) {
+	c := newCancelCtx(parent)
+	c.propagateCancel(parent, c)
+	return c, func() { c.cancel(true, Canceled) }
+}
+
+type timerCtx struct {
+	cancelCtx
+	timer    *time.Timer // guarded by cancelCtx.mutex
+	deadline time.Time
+}
+
+func (c *timerCtx) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+func (c *timerCtx) cancel(removeFromParent bool, err error) {
+	c.cancelCtx.cancel(false, err)
+	if removeFromParent {
+		removeChild(c.cancelCtx.Context, c)
+		if c.detach != nil {
+			c.detach()
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+}
+
+// WithDeadline uses time.AfterFunc, so no goroutine waits for the deadline
+func WithDeadline(parent context.Context, deadline time.Time) (context.Context, context.CancelFunc) {
+	if current, ok := parent.Deadline(); ok && current.Before(deadline) {
+		return WithCancel(parent) // the parent is canceled earlier
+	}
+
+	c := &timerCtx{
+		cancelCtx: *newCancelCtx(parent),
+		deadline:  deadline,
+	}
+
+	c.propagateCancel(parent, c)
+
+	duration := time.Until(deadline)
+	if duration <= 0 {
+		c.cancel(true, DeadlineExceeded)
+		return c, func() { c.cancel(false, Canceled) }
+	}
+
+	c.mutex.Lock()
+	if c.err == nil {
+		c.timer = time.AfterFunc(duration, func() {
+			c.cancel(true, DeadlineExceeded)
+		})
+	}
+	c.mutex.Unlock()
+
+	return c, func() { c.cancel(true, Canceled) }
+}
+
+func WithTimeout(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return WithDeadline(parent, time.Now().Add(timeout))
+}
+
+type valueCtx struct {
+	context.Context // parent
+	key, value      any
+}
+
+func (c *valueCtx) Value(key any) any {
+	if c.key == key {
+		return c.value
+	}
+
+	return c.Context.Value(key)
+}
+
+func WithValue(parent context.Context, key, value any) context.Context {
+	if parent == nil {
+		panic("cannot create context from nil parent")
+	}
+	if key == nil {
+		panic("nil key")
+	}
+	if !reflect.TypeOf(key).Comparable() {
+		panic("key is not comparable")
+	}
+
+	return &valueCtx{Context: parent, key: key, value: value}
+}
+
+// withoutCancelCtx keeps values of the parent only
+type withoutCancelCtx struct {
+	parent context.Context
+}
+
+func (withoutCancelCtx) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (withoutCancelCtx) Done() <-chan struct{}       { return nil }
+func (withoutCancelCtx) Err() error                  { return nil }
+
+func (c withoutCancelCtx) Value(key any) any {
+	if key == &cancelCtxKey {
+		return nil // children mustn't be registered in the canceled parent
+	}
+
+	return c.parent.Value(key)
+}
+
+func WithoutCancel(parent context.Context) context.Context {
+	if parent == nil {
+		panic("cannot create context from nil parent")
+	}
+
+	return withoutCancelCtx{parent: parent}
 }
 
-func (c *Context) Deadline() (time.Time, bool) {
-	// not implemented
-	return time.Time{}, false
+type afterFuncCtx struct {
+	once sync.Once
+	fn   func()
 }
 
-func (c *Context) Value(any) any {
-	// not implemented
-	return nil
+func (a *afterFuncCtx) cancel(_ bool, _ error) {
+	a.once.Do(func() {
+		go a.fn()
+	})
+}
+
+// AfterFunc calls fn in its own goroutine after ctx is done, stop returns
+// false if fn has already been started or stopped
+func AfterFunc(ctx context.Context, fn func()) (stop func() bool) {
+	p, ok := parentCancelCtx(ctx)
+	if !ok {
+		return context.AfterFunc(ctx, fn)
+	}
+
+	a := &afterFuncCtx{fn: fn}
+	if !p.addChild(a) {
+		a.cancel(false, p.Err())
+	}
+
+	return func() bool {
+		stopped := false
+		a.once.Do(func() {
+			stopped = true
+		})
+
+		if stopped {
+			removeChild(ctx, a)
+		}
+
+		return stopped
+	}
 }
 
 func main() {
-	ctx, cancel := WithTimeout(Context{}, time.Second)
+	ctx, cancel := WithTimeout(Background(), time.Second)
 	defer cancel()
 
 	timer := time.NewTimer(5 * time.Second)
@@ -76,6 +340,6 @@ func main() {
 	case <-timer.C:
 		fmt.Println("finished")
 	case <-ctx.Done():
-		fmt.Println("canceled")
+		fmt.Println("canceled:", ctx.Err())
 	}
 }
@@ -0,0 +1,105 @@
+// Package ctxkey provides typed context keys, keys are declared once as
+// package variables and compared by pointer, so they never collide and
+// values are read without type assertions:
+//
+//	var TraceID = ctxkey.New[string]("trace_id")
+//
+//	ctx = ctxkey.WithValue(ctx, TraceID, "12-21-33")
+//	traceID, ok := TraceID.Value(ctx)
+package ctxkey
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+type Key[T any] struct {
+	name string
+}
+
+func New[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+func (k *Key[T]) String() string {
+	return k.name
+}
+
+func WithValue[T any](ctx context.Context, key *Key[T], value T) context.Context {
+	return context.WithValue(ctx, key, value)
+}
+
+func (k *Key[T]) Value(ctx context.Context) (T, bool) {
+	value, ok := ctx.Value(k).(T)
+	return value, ok
+}
+
+// MustValue panics if there is no value, it is used
+// when the value is set by a required middleware
+func (k *Key[T]) MustValue(ctx context.Context) T {
+	value, ok := k.Value(ctx)
+	if !ok {
+		panic(fmt.Sprintf("ctxkey: no value for %q", k.name))
+	}
+
+	return value
+}
+
+const inlineEntries = 8
+
+type entry struct {
+	key   any
+	value any
+}
+
+// Scope is a mutable bag of request values kept in one context layer,
+// the first inlineEntries values don't need allocations besides the scope itself.
+// Values are read through any context derived from the one returned by WithScope,
+// but only the holder of the *Scope (the goroutine that owns the request) may
+// write them, so a value set later is visible to every context of the request
+type Scope struct {
+	context.Context // parent
+
+	mutex   sync.RWMutex
+	entries []entry
+	inline  [inlineEntries]entry
+}
+
+func (s *Scope) Value(key any) any {
+	s.mutex.RLock()
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		if s.entries[i].key == key {
+			value := s.entries[i].value
+			s.mutex.RUnlock()
+			return value
+		}
+	}
+	s.mutex.RUnlock()
+
+	return s.Context.Value(key)
+}
+
+// WithScope adds the scope for values set by Set,
+// usually it is called once per request by the first middleware
+// that keeps the returned *Scope to itself
+func WithScope(ctx context.Context) (context.Context, *Scope) {
+	s := &Scope{Context: ctx}
+	s.entries = s.inline[:0]
+	return s, s
+}
+
+// Set stores the value in the scope without creating a new context
+func Set[T any](s *Scope, key *Key[T], value T) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i := range s.entries {
+		if s.entries[i].key == any(key) {
+			s.entries[i].value = value
+			return
+		}
+	}
+
+	s.entries = append(s.entries, entry{key: key, value: value})
+}
@@ -0,0 +1,84 @@
+package ctxkey
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKey(t *testing.T) {
+	traceID := New[string]("trace_id")
+	sameName := New[string]("trace_id")
+	userID := New[int]("user_id")
+
+	ctx := WithValue(context.Background(), traceID, "12-21-33")
+	ctx = WithValue(ctx, userID, 42)
+
+	value, ok := traceID.Value(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "12-21-33", value)
+	assert.Equal(t, 42, userID.MustValue(ctx))
+
+	_, ok = sameName.Value(ctx)
+	assert.False(t, ok)
+	assert.Nil(t, ctx.Value("trace_id"))
+	assert.PanicsWithValue(t, `ctxkey: no value for "trace_id"`, func() {
+		sameName.MustValue(ctx)
+	})
+
+	ctx = WithValue(ctx, traceID, "shadowed")
+	assert.Equal(t, "shadowed", traceID.MustValue(ctx))
+}
+
+func TestScope(t *testing.T) {
+	traceID := New[string]("trace_id")
+	userID := New[int]("user_id")
+
+	parent := WithValue(context.Background(), userID, 1)
+	ctx, scope := WithScope(parent)
+	_, ok := traceID.Value(ctx)
+	assert.False(t, ok)
+	assert.Equal(t, 1, userID.MustValue(ctx))
+
+	child, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	Set(scope, traceID, "12-21-33")
+	Set(scope, userID, 2)
+	Set(scope, userID, 3)
+
+	assert.Equal(t, "12-21-33", traceID.MustValue(ctx))
+	assert.Equal(t, 3, userID.MustValue(child))
+	assert.Equal(t, 1, userID.MustValue(parent))
+
+	shadowed := WithValue(child, userID, 4)
+	assert.Equal(t, 4, userID.MustValue(shadowed))
+	assert.Equal(t, 3, userID.MustValue(ctx))
+
+	keys := make([]*Key[int], 2*inlineEntries)
+	for i := range keys {
+		keys[i] = New[int]("key")
+		Set(scope, keys[i], i)
+	}
+
+	for i, key := range keys {
+		assert.Equal(t, i, key.MustValue(child))
+	}
+}
+
+func TestScopeAllocations(t *testing.T) {
+	keys := make([]*Key[int], inlineEntries)
+	for i := range keys {
+		keys[i] = New[int]("key")
+	}
+
+	allocations := testing.AllocsPerRun(100, func() {
+		_, scope := WithScope(context.Background())
+		for i, key := range keys {
+			Set(scope, key, i) // small integers are boxed without allocations
+		}
+	})
+
+	assert.Equal(t, 1.0, allocations)
+}
@@ -2,40 +2,91 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
-	"os"
-	"os/signal"
+	"sync"
 	"time"
-)
 
-func main() {
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
-	defer stop()
+	"golang_course/lessons/contexts/lifecycle"
+)
 
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+func httpServer(addr string) lifecycle.Component {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		_, _ = io.WriteString(w, "hello world\n")
 	})
 
 	server := &http.Server{
-		Addr: ":8888",
+		Addr:    addr,
+		Handler: mux,
+	}
+
+	return lifecycle.Component{
+		Name:      "http",
+		DependsOn: []string{"worker"},
+		Start: func(context.Context) error {
+			listener, err := net.Listen("tcp", server.Addr)
+			if err != nil {
+				return err // the error of listening is returned from Start
+			}
+
+			go func() {
+				if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					log.Print(err.Error())
+				}
+			}()
+
+			return nil
+		},
+		Stop:        server.Shutdown,
+		StopTimeout: 5 * time.Second,
 	}
+}
+
+func worker() lifecycle.Component {
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := sync.WaitGroup{}
 
-	go func() {
-		err := server.ListenAndServe()
-		if err != nil && err != http.ErrServerClosed {
-			log.Print(err.Error()) // exit
-		}
-	}()
+	return lifecycle.Component{
+		Name: "worker",
+		Start: func(context.Context) error {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
 
-	<-ctx.Done()
+				ticker := time.NewTicker(time.Second)
+				defer ticker.Stop()
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	cancel()
+				for {
+					select {
+					case <-ticker.C:
+						fmt.Println("working")
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+
+			return nil
+		},
+		Stop: func(context.Context) error {
+			cancel()
+			wg.Wait()
+			return nil
+		},
+	}
+}
+
+func main() {
+	manager := lifecycle.New(time.Second)
+	_ = manager.Register(httpServer(":8888"))
+	_ = manager.Register(worker())
 
-	if err := server.Shutdown(ctx); err != nil {
+	if err := manager.Run(context.Background()); err != nil {
 		log.Print(err.Error())
 	}
 
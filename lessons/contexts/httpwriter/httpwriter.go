@@ -0,0 +1,89 @@
+// Package httpwriter provides the http.ResponseWriter wrapper
+// shared by middlewares that need the status and the size of the response
+package httpwriter
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter remembers the status and the size of the response
+type ResponseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+// Wrap returns w itself if it is already wrapped,
+// so stacked middlewares see the same status
+func Wrap(w http.ResponseWriter) *ResponseWriter {
+	if rw, ok := w.(*ResponseWriter); ok {
+		return rw
+	}
+
+	return &ResponseWriter{ResponseWriter: w}
+}
+
+func (w *ResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *ResponseWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(data)
+	w.size += int64(n)
+	return n, err
+}
+
+// Unwrap is used by http.ResponseController
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Flush keeps streaming responses working behind the wrapper,
+// it sends the header, so the status is written
+func (w *ResponseWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack keeps connection upgrades working behind the wrapper,
+// nothing may be written to the response after it
+func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+
+	return conn, rw, err
+}
+
+// Written reports whether the header has been sent
+func (w *ResponseWriter) Written() bool {
+	return w.status != 0
+}
+
+// Status returns http.StatusOK if nothing has been written,
+// as net/http does when the handler returns
+func (w *ResponseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+
+	return w.status
+}
+
+func (w *ResponseWriter) Size() int64 {
+	return w.size
+}
@@ -0,0 +1,57 @@
+package httpwriter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseWriter(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writer := Wrap(recorder)
+	assert.Same(t, writer, Wrap(writer))
+	assert.False(t, writer.Written())
+	assert.Equal(t, http.StatusOK, writer.Status())
+
+	writer.WriteHeader(http.StatusTeapot)
+	writer.WriteHeader(http.StatusInternalServerError) // superfluous call
+	_, _ = writer.Write([]byte("tea"))
+
+	assert.True(t, writer.Written())
+	assert.Equal(t, http.StatusTeapot, writer.Status())
+	assert.Equal(t, int64(3), writer.Size())
+	assert.Same(t, http.ResponseWriter(recorder), writer.Unwrap())
+}
+
+func TestResponseWriterImplicitStatus(t *testing.T) {
+	writer := Wrap(httptest.NewRecorder())
+	_, _ = writer.Write([]byte("ok"))
+
+	assert.True(t, writer.Written())
+	assert.Equal(t, http.StatusOK, writer.Status())
+}
+
+func TestResponseWriterFlush(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	var w http.ResponseWriter = Wrap(recorder)
+
+	flusher, ok := w.(http.Flusher)
+	assert.True(t, ok)
+	flusher.Flush()
+
+	assert.True(t, recorder.Flushed)
+	assert.True(t, Wrap(w).Written())
+	assert.Equal(t, http.StatusOK, Wrap(w).Status())
+}
+
+func TestResponseWriterHijack(t *testing.T) {
+	var w http.ResponseWriter = Wrap(httptest.NewRecorder())
+
+	hijacker, ok := w.(http.Hijacker)
+	assert.True(t, ok)
+
+	_, _, err := hijacker.Hijack()
+	assert.ErrorIs(t, err, http.ErrNotSupported) // the recorder can't be hijacked
+}
@@ -0,0 +1,313 @@
+// Package lifecycle starts components in the order of their dependencies
+// and stops them in the reverse order on SIGINT or SIGTERM
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrDuplicateComponent = errors.New("lifecycle: duplicate component")
+	ErrUnknownDependency  = errors.New("lifecycle: unknown dependency")
+	ErrDependencyCycle    = errors.New("lifecycle: dependency cycle")
+	ErrAlreadyStarted     = errors.New("lifecycle: already started")
+	ErrForcedExit         = errors.New("lifecycle: forced exit")
+)
+
+// Component's Start has to return after starting,
+// long-running work is done in its own goroutines
+type Component struct {
+	Name        string
+	Start       func(ctx context.Context) error // optional
+	Stop        func(ctx context.Context) error // optional
+	StopTimeout time.Duration                   // default timeout of the manager if zero, no timeout if both are zero
+	DependsOn   []string                        // started before and stopped after this component
+}
+
+type ComponentError struct {
+	Component string
+	Err       error
+}
+
+func (e ComponentError) Error() string {
+	return e.Component + ": " + e.Err.Error()
+}
+
+func (e ComponentError) Unwrap() error {
+	return e.Err
+}
+
+// StopError reports components failed to stop or stopped too late
+type StopError struct {
+	Failures []ComponentError
+}
+
+func (e *StopError) Components() []string {
+	names := make([]string, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		names = append(names, failure.Component)
+	}
+
+	return names
+}
+
+func (e *StopError) Error() string {
+	messages := make([]string, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		messages = append(messages, failure.Error())
+	}
+
+	return "lifecycle: failed to stop: " + strings.Join(messages, "; ")
+}
+
+func (e *StopError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		errs = append(errs, failure)
+	}
+
+	return errs
+}
+
+type Lifecycle struct {
+	stopTimeout time.Duration
+	exit        func(code int) // replaced in tests
+
+	stopMutex sync.Mutex // concurrent Stop calls wait for the first one
+
+	mutex      sync.Mutex
+	components []Component
+	started    []Component // in the order of starting
+	running    bool
+}
+
+// New creates the manager, zero stopTimeout means waiting for Stop hooks
+// of components without their own timeout until ctx of Stop is done
+func New(stopTimeout time.Duration) *Lifecycle {
+	return &Lifecycle{
+		stopTimeout: stopTimeout,
+		exit:        os.Exit,
+	}
+}
+
+func (l *Lifecycle) Register(component Component) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, registered := range l.components {
+		if registered.Name == component.Name {
+			return fmt.Errorf("%w: %s", ErrDuplicateComponent, component.Name)
+		}
+	}
+
+	l.components = append(l.components, component)
+	return nil
+}
+
+// order sorts components topologically keeping the order of registration
+func (l *Lifecycle) order() ([]Component, error) {
+	byName := make(map[string]Component, len(l.components))
+	for _, component := range l.components {
+		byName[component.Name] = component
+	}
+
+	const (
+		visiting = iota + 1
+		visited
+	)
+
+	states := make(map[string]int, len(l.components))
+	ordered := make([]Component, 0, len(l.components))
+
+	var visit func(component Component) error
+	visit = func(component Component) error {
+		switch states[component.Name] {
+		case visiting:
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, component.Name)
+		case visited:
+			return nil
+		}
+
+		states[component.Name] = visiting
+		for _, name := range component.DependsOn {
+			dependency, found := byName[name]
+			if !found {
+				return fmt.Errorf("%w: %s depends on %s", ErrUnknownDependency, component.Name, name)
+			}
+
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+
+		states[component.Name] = visited
+		ordered = append(ordered, component)
+		return nil
+	}
+
+	for _, component := range l.components {
+		if err := visit(component); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+// Start stops already started components if one of them fails
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mutex.Lock()
+	if l.running {
+		l.mutex.Unlock()
+		return ErrAlreadyStarted
+	}
+
+	ordered, err := l.order()
+	if err != nil {
+		l.mutex.Unlock()
+		return err
+	}
+
+	l.running = true
+	l.mutex.Unlock()
+
+	for _, component := range ordered {
+		if component.Start != nil {
+			if err := component.Start(ctx); err != nil {
+				startErr := ComponentError{Component: component.Name, Err: err}
+				return errors.Join(startErr, l.Stop(context.WithoutCancel(ctx)))
+			}
+		}
+
+		l.mutex.Lock()
+		l.started = append(l.started, component)
+		l.mutex.Unlock()
+	}
+
+	return nil
+}
+
+func (l *Lifecycle) stopComponent(ctx context.Context, component Component) error {
+	timeout := component.StopTimeout
+	if timeout == 0 {
+		timeout = l.stopTimeout
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// the hook can ignore ctx, it is abandoned after the timeout
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- component.Stop(ctx)
+	}()
+
+	select {
+	case err := <-stopped:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stop stops components in the reverse order, every one of them
+// is stopped even if the previous ones failed
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	// the stopping component stays in started for Running,
+	// so Stop calls are serialized to stop it only once
+	l.stopMutex.Lock()
+	defer l.stopMutex.Unlock()
+
+	var failures []ComponentError
+	for {
+		l.mutex.Lock()
+		if len(l.started) == 0 {
+			l.running = false
+			l.mutex.Unlock()
+			break
+		}
+
+		component := l.started[len(l.started)-1]
+		l.mutex.Unlock()
+
+		if component.Stop != nil {
+			if err := l.stopComponent(ctx, component); err != nil {
+				failures = append(failures, ComponentError{Component: component.Name, Err: err})
+			}
+		}
+
+		l.mutex.Lock()
+		l.started = l.started[:len(l.started)-1]
+		l.mutex.Unlock()
+	}
+
+	if len(failures) != 0 {
+		return &StopError{Failures: failures}
+	}
+
+	return nil
+}
+
+// Running returns started components which aren't stopped yet
+func (l *Lifecycle) Running() []string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	names := make([]string, 0, len(l.started))
+	for _, component := range slices.Backward(l.started) {
+		names = append(names, component.Name)
+	}
+
+	return names
+}
+
+// Run starts components and stops them after SIGINT, SIGTERM or canceling ctx,
+// the second signal during stopping exits the process immediately
+func (l *Lifecycle) Run(ctx context.Context) error {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	if err := l.Start(ctx); err != nil {
+		return err
+	}
+
+	select {
+	case <-signals:
+	case <-ctx.Done():
+	}
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- l.Stop(context.WithoutCancel(ctx))
+	}()
+
+	select {
+	case err := <-stopped:
+		return err
+	case <-signals:
+		running := l.Running()
+		log.Printf("lifecycle: forced exit, not stopped: %s", strings.Join(running, ", "))
+		l.exit(1)
+
+		failures := make([]ComponentError, 0, len(running))
+		for _, name := range running {
+			failures = append(failures, ComponentError{Component: name, Err: ErrForcedExit})
+		}
+
+		return &StopError{Failures: failures}
+	}
+}
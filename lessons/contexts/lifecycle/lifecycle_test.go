@@ -0,0 +1,258 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -race -v .
+
+type journal struct {
+	mutex   sync.Mutex
+	entries []string
+}
+
+func (j *journal) add(entry string) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.entries = append(j.entries, entry)
+}
+
+func (j *journal) get() []string {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return append([]string(nil), j.entries...)
+}
+
+func (j *journal) component(name string, dependsOn ...string) Component {
+	return Component{
+		Name:      name,
+		DependsOn: dependsOn,
+		Start: func(context.Context) error {
+			j.add("start " + name)
+			return nil
+		},
+		Stop: func(context.Context) error {
+			j.add("stop " + name)
+			return nil
+		},
+	}
+}
+
+func TestLifecycleOrder(t *testing.T) {
+	j := &journal{}
+	l := New(time.Second)
+	assert.NoError(t, l.Register(j.component("http", "db", "cache")))
+	assert.NoError(t, l.Register(j.component("worker", "db")))
+	assert.NoError(t, l.Register(j.component("db")))
+	assert.NoError(t, l.Register(j.component("cache")))
+	assert.ErrorIs(t, l.Register(j.component("db")), ErrDuplicateComponent)
+
+	assert.NoError(t, l.Start(context.Background()))
+	assert.ErrorIs(t, l.Start(context.Background()), ErrAlreadyStarted)
+	assert.Equal(t, []string{"worker", "http", "cache", "db"}, l.Running())
+
+	assert.NoError(t, l.Stop(context.Background()))
+	assert.Empty(t, l.Running())
+	assert.Equal(t, []string{
+		"start db", "start cache", "start http", "start worker",
+		"stop worker", "stop http", "stop cache", "stop db",
+	}, j.get())
+}
+
+func TestLifecycleInvalidDependencies(t *testing.T) {
+	j := &journal{}
+
+	l := New(time.Second)
+	_ = l.Register(j.component("http", "db"))
+	assert.ErrorIs(t, l.Start(context.Background()), ErrUnknownDependency)
+
+	l = New(time.Second)
+	_ = l.Register(j.component("a", "b"))
+	_ = l.Register(j.component("b", "c"))
+	_ = l.Register(j.component("c", "a"))
+	assert.ErrorIs(t, l.Start(context.Background()), ErrDependencyCycle)
+
+	assert.Empty(t, j.get())
+}
+
+func TestLifecycleStartFailure(t *testing.T) {
+	j := &journal{}
+	failure := errors.New("failure")
+
+	l := New(time.Second)
+	_ = l.Register(j.component("db"))
+	_ = l.Register(j.component("cache"))
+	_ = l.Register(Component{
+		Name:  "http",
+		Start: func(context.Context) error { return failure },
+		Stop:  func(context.Context) error { panic("isn't started") },
+	})
+
+	err := l.Start(context.Background())
+	assert.ErrorIs(t, err, failure)
+
+	var componentErr ComponentError
+	assert.ErrorAs(t, err, &componentErr)
+	assert.Equal(t, "http", componentErr.Component)
+	assert.Equal(t, []string{"start db", "start cache", "stop cache", "stop db"}, j.get())
+}
+
+func TestLifecycleStopTimeout(t *testing.T) {
+	j := &journal{}
+	failure := errors.New("failure")
+	release := make(chan struct{})
+	defer close(release)
+
+	l := New(time.Hour)
+	_ = l.Register(j.component("db"))
+	_ = l.Register(Component{
+		Name:        "stuck",
+		StopTimeout: 20 * time.Millisecond,
+		Stop: func(context.Context) error {
+			<-release // ignores ctx
+			return nil
+		},
+	})
+	_ = l.Register(Component{
+		Name: "broken",
+		Stop: func(context.Context) error { return failure },
+	})
+
+	assert.NoError(t, l.Start(context.Background()))
+
+	err := l.Stop(context.Background())
+	assert.ErrorIs(t, err, failure)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	var stopErr *StopError
+	assert.ErrorAs(t, err, &stopErr)
+	assert.Equal(t, []string{"broken", "stuck"}, stopErr.Components())
+	assert.Equal(t, []string{"start db", "stop db"}, j.get())
+}
+
+func TestLifecycleWithoutStopTimeout(t *testing.T) {
+	j := &journal{}
+	l := New(0)
+	_ = l.Register(Component{
+		Name: "slow",
+		Stop: func(ctx context.Context) error {
+			time.Sleep(20 * time.Millisecond)
+			return ctx.Err()
+		},
+	})
+	_ = l.Register(j.component("db"))
+
+	assert.NoError(t, l.Start(context.Background()))
+	assert.NoError(t, l.Stop(context.Background()))
+	assert.Equal(t, []string{"start db", "stop db"}, j.get())
+}
+
+func TestLifecycleConcurrentStop(t *testing.T) {
+	j := &journal{}
+	l := New(time.Second)
+	_ = l.Register(j.component("db"))
+	_ = l.Register(Component{
+		Name:      "http",
+		DependsOn: []string{"db"},
+		Stop: func(context.Context) error {
+			time.Sleep(10 * time.Millisecond) // concurrent calls see it still running
+			j.add("stop http")
+			return nil
+		},
+	})
+
+	assert.NoError(t, l.Start(context.Background()))
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, l.Stop(context.Background()))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, []string{"start db", "stop http", "stop db"}, j.get())
+	assert.Empty(t, l.Running())
+}
+
+func TestLifecycleRun(t *testing.T) {
+	j := &journal{}
+	started := make(chan struct{})
+
+	l := New(time.Second)
+	_ = l.Register(j.component("db"))
+	_ = l.Register(Component{
+		Name:      "http",
+		DependsOn: []string{"db"},
+		Start: func(context.Context) error {
+			close(started)
+			return nil
+		},
+	})
+
+	go func() {
+		<-started
+		_ = syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+	}()
+
+	assert.NoError(t, l.Run(context.Background()))
+	assert.Equal(t, []string{"start db", "stop db"}, j.get())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l = New(time.Second)
+	_ = l.Register(j.component("cache"))
+	assert.NoError(t, l.Run(ctx))
+}
+
+func TestLifecycleForcedExit(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	stopping := make(chan struct{})
+	started := make(chan struct{})
+
+	l := New(time.Hour)
+	exitCode := 0
+	l.exit = func(code int) { exitCode = code }
+
+	_ = l.Register(Component{Name: "db"})
+	_ = l.Register(Component{
+		Name: "stuck",
+		Start: func(context.Context) error {
+			close(started)
+			return nil
+		},
+		Stop: func(context.Context) error {
+			close(stopping)
+			<-release
+			return nil
+		},
+	})
+
+	go func() {
+		<-started
+		_ = syscall.Kill(syscall.Getpid(), syscall.SIGINT)
+		<-stopping
+		_ = syscall.Kill(syscall.Getpid(), syscall.SIGINT)
+	}()
+
+	err := l.Run(context.Background())
+	assert.ErrorIs(t, err, ErrForcedExit)
+	assert.Equal(t, 1, exitCode)
+
+	var stopErr *StopError
+	assert.ErrorAs(t, err, &stopErr)
+	assert.Equal(t, []string{"stuck", "db"}, stopErr.Components())
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"golang_course/lessons/contexts/httpwriter"
+	"golang_course/lessons/sync_primitives/semaphore"
+)
+
+// Timeout cancels the request context after timeout, handlers have to respect it,
+// 503 is sent if the handler returns after the timeout without a response
+func Timeout(timeout time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			writer := httpwriter.Wrap(w)
+			next.ServeHTTP(writer, r.WithContext(ctx))
+
+			if !writer.Written() && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				http.Error(writer, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			}
+		})
+	}
+}
+
+// MaxBodySize rejects requests with a known large body with 413 at once,
+// others fail on reading more than limit bytes
+func MaxBodySize(limit int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ConcurrencyLimit handles a request only after acquiring the unit of sem,
+// the request waits at most maxWait and then gets 503
+func ConcurrencyLimit(sem *semaphore.Semaphore, maxWait time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			acquired := sem.TryAcquire(1)
+			if !acquired && maxWait > 0 {
+				ctx, cancel := context.WithTimeout(r.Context(), maxWait)
+				acquired = sem.Acquire(ctx, 1) == nil
+				cancel()
+			}
+
+			if !acquired {
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+				return
+			}
+
+			defer sem.Release(1)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Package middleware contains composable net/http middlewares:
+//
+//	handler := middleware.Chain(
+//		middleware.RequestID(),
+//		middleware.AccessLog(logger),
+//		middleware.Recover(logger),
+//	)(mux)
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"golang_course/lessons/contexts/ctxkey"
+	"golang_course/lessons/contexts/httpwriter"
+)
+
+type Middleware = func(http.Handler) http.Handler
+
+// Chain applies middlewares in the order of arguments,
+// so the first one sees the request first
+func Chain(middlewares ...Middleware) Middleware {
+	return func(handler http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			handler = middlewares[i](handler)
+		}
+
+		return handler
+	}
+}
+
+func loggerOrDefault(logger *log.Logger) *log.Logger {
+	if logger == nil {
+		return log.Default()
+	}
+
+	return logger
+}
+
+// Recover responds with 500 and logs the stack if the handler panics,
+// http.ErrAbortHandler is passed to the server to abort the response
+func Recover(logger *log.Logger) Middleware {
+	logger = loggerOrDefault(logger)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writer := httpwriter.Wrap(w)
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(v)
+				}
+
+				logger.Printf("captured panic: %v %s: %v\n%s", r.Method, r.URL.Path, v, debug.Stack())
+				if !writer.Written() {
+					http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+
+			next.ServeHTTP(writer, r)
+		})
+	}
+}
+
+const (
+	RequestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
+)
+
+var requestIDKey = ctxkey.New[string]("request_id")
+
+// RequestIDFromContext returns the empty string without RequestID middleware
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := requestIDKey.Value(ctx)
+	return id
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// RequestID keeps a valid incoming request ID or generates a new one,
+// the ID is put into the context and the response header
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+
+			w.Header().Set(RequestIDHeader, id)
+			next.ServeHTTP(w, r.WithContext(ctxkey.WithValue(r.Context(), requestIDKey, id)))
+		})
+	}
+}
+
+// AccessLog logs every request after the handler returns
+func AccessLog(logger *log.Logger) Middleware {
+	logger = loggerOrDefault(logger)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			writer := httpwriter.Wrap(w)
+			defer func() {
+				id := RequestIDFromContext(r.Context())
+				if id == "" {
+					id = "-"
+				}
+
+				logger.Printf("%s %s %s %d %d %s %s",
+					r.RemoteAddr, r.Method, r.URL.RequestURI(), writer.Status(), writer.Size(), time.Since(start), id)
+			}()
+
+			next.ServeHTTP(writer, r)
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"golang_course/lessons/sync_primitives/semaphore"
+)
+
+// go test -race -v .
+
+func serve(handler http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, r)
+	return recorder
+}
+
+func TestChain(t *testing.T) {
+	var calls []string
+	named := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	handler := Chain(named("first"), named("second"))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		calls = append(calls, "handler")
+	}))
+
+	serve(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, []string{"first", "second", "handler"}, calls)
+}
+
+func TestRecover(t *testing.T) {
+	var output bytes.Buffer
+	logger := log.New(&output, "", 0)
+
+	handler := Recover(logger)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("internal error")
+	}))
+
+	response := serve(handler, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	assert.Equal(t, http.StatusInternalServerError, response.Code)
+	assert.Contains(t, output.String(), "captured panic: GET /panic: internal error")
+	assert.Contains(t, output.String(), "goroutine")
+
+	handler = Recover(logger)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("after writing")
+	}))
+
+	response = serve(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusAccepted, response.Code)
+
+	handler = Recover(logger)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		serve(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
+
+func TestRequestID(t *testing.T) {
+	var fromContext string
+	handler := RequestID()(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		fromContext = RequestIDFromContext(r.Context())
+	}))
+
+	response := serve(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	id := response.Header().Get(RequestIDHeader)
+	assert.Len(t, id, 32)
+	assert.Equal(t, id, fromContext)
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(RequestIDHeader, "incoming-id")
+	response = serve(handler, request)
+	assert.Equal(t, "incoming-id", response.Header().Get(RequestIDHeader))
+	assert.Equal(t, "incoming-id", fromContext)
+
+	request.Header.Set(RequestIDHeader, "invalid id")
+	response = serve(handler, request)
+	assert.Len(t, response.Header().Get(RequestIDHeader), 32)
+
+	assert.Empty(t, RequestIDFromContext(request.Context()))
+}
+
+func TestAccessLog(t *testing.T) {
+	var output bytes.Buffer
+	logger := log.New(&output, "", 0)
+
+	handler := Chain(RequestID(), AccessLog(logger))(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, "hello")
+	}))
+
+	request := httptest.NewRequest(http.MethodPost, "/items?id=1", nil)
+	request.Header.Set(RequestIDHeader, "request-1")
+	serve(handler, request)
+
+	assert.True(t, strings.HasPrefix(output.String(), request.RemoteAddr+" POST /items?id=1 201 5 "))
+	assert.True(t, strings.HasSuffix(output.String(), " request-1\n"))
+
+	output.Reset()
+	serve(AccessLog(logger)(http.NotFoundHandler()), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Contains(t, output.String(), " GET / 404 ")
+	assert.True(t, strings.HasSuffix(output.String(), " -\n"))
+}
+
+func TestTimeout(t *testing.T) {
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+			w.WriteHeader(http.StatusOK)
+		case <-r.Context().Done():
+		}
+	})
+
+	response := serve(Timeout(20*time.Millisecond)(slow), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+
+	fast := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	response = serve(Timeout(time.Second)(fast), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusNoContent, response.Code)
+}
+
+func TestMaxBodySize(t *testing.T) {
+	var readErr error
+	handler := MaxBodySize(4)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}))
+
+	response := serve(handler, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello world")))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, response.Code)
+
+	request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello world"))
+	request.ContentLength = -1 // unknown like for chunked bodies
+	serve(handler, request)
+
+	var maxBytesErr *http.MaxBytesError
+	assert.True(t, errors.As(readErr, &maxBytesErr))
+
+	serve(handler, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hey")))
+	assert.NoError(t, readErr)
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	sem := semaphore.NewSemaphore(1)
+	entered := make(chan struct{})
+	release := make(chan struct{})
+
+	blocking := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		entered <- struct{}{}
+		<-release
+	})
+
+	done := make(chan int)
+	go func() {
+		response := serve(ConcurrencyLimit(sem, 0)(blocking), httptest.NewRequest(http.MethodGet, "/", nil))
+		done <- response.Code
+	}()
+	<-entered
+
+	ok := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	response := serve(ConcurrencyLimit(sem, 0)(ok), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	assert.Equal(t, "1", response.Header().Get("Retry-After"))
+
+	response = serve(ConcurrencyLimit(sem, 20*time.Millisecond)(ok), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+
+	response = serve(ConcurrencyLimit(sem, time.Second)(ok), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, http.StatusOK, <-done)
+	assert.True(t, sem.TryAcquire(1))
+}
@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang_course/lessons/contexts/httpwriter"
+)
+
+// Inject sets headers of the span from ctx, nothing is set without the span
+func Inject(ctx context.Context, header http.Header) {
+	sc, ok := SpanContextFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	header.Set(traceparentHeader, sc.Traceparent())
+	if sc.State.Len() != 0 {
+		header.Set(tracestateHeader, sc.State.String())
+	} else {
+		header.Del(tracestateHeader)
+	}
+}
+
+// Extract returns ctx with the remote span, invalid headers are ignored
+// and tracestate is dropped without a valid traceparent
+func Extract(ctx context.Context, header http.Header) context.Context {
+	sc, err := ParseTraceparent(header.Get(traceparentHeader))
+	if err != nil {
+		return ctx
+	}
+
+	// multiple headers are combined as a list
+	sc.State, _ = ParseTracestate(strings.Join(header.Values(tracestateHeader), ","))
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// Middleware creates the server span for every request
+// continuing the trace from incoming headers
+func (t *Tracer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := t.Start(Extract(r.Context(), r.Header), r.Method+" "+r.URL.Path)
+		defer span.End()
+
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.path", r.URL.Path)
+
+		writer := httpwriter.Wrap(w)
+		next.ServeHTTP(writer, r.WithContext(ctx))
+
+		span.SetAttribute("http.status_code", strconv.Itoa(writer.Status()))
+	})
+}
+
+// Transport creates the client span for every request and injects its headers
+type Transport struct {
+	Tracer *Tracer
+	Base   http.RoundTripper // http.DefaultTransport if nil
+}
+
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	ctx, span := t.Tracer.Start(r.Context(), "HTTP "+r.Method)
+	defer span.End()
+
+	span.SetAttribute("http.method", r.Method)
+	span.SetAttribute("http.url", r.URL.String())
+
+	r = r.Clone(ctx) // RoundTrip mustn't change the request
+	Inject(ctx, r.Header)
+
+	response, err := base.RoundTrip(r)
+	if err != nil {
+		span.SetAttribute("error", err.Error())
+		return nil, err
+	}
+
+	span.SetAttribute("http.status_code", strconv.Itoa(response.StatusCode))
+	return response, nil
+}
+
+// Client returns the copy of client with the tracing transport
+func (t *Tracer) Client(client *http.Client) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	traced := *client
+	traced.Transport = &Transport{Tracer: t, Base: client.Transport}
+	return &traced
+}
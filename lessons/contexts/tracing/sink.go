@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"slices"
+	"sync"
+)
+
+// Sink receives finished spans, it has to be safe for concurrent use
+type Sink interface {
+	Export(SpanData)
+}
+
+// JSONSink writes one JSON object per line
+type JSONSink struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+}
+
+func NewJSONSink(w io.Writer) *JSONSink {
+	return &JSONSink{encoder: json.NewEncoder(w)}
+}
+
+func NewStdoutSink() *JSONSink {
+	return NewJSONSink(os.Stdout)
+}
+
+func (s *JSONSink) Export(data SpanData) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_ = s.encoder.Encode(data) // tracing mustn't break the traced code
+}
+
+// Recorder keeps spans in memory for tests
+type Recorder struct {
+	mutex sync.Mutex
+	spans []SpanData
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (r *Recorder) Export(data SpanData) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.spans = append(r.spans, data)
+}
+
+// Spans returns spans in the order they are ended
+func (r *Recorder) Spans() []SpanData {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return slices.Clone(r.spans)
+}
+
+func (r *Recorder) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.spans = nil
+}
@@ -0,0 +1,162 @@
+package tracing
+
+import (
+	"context"
+	"maps"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"golang_course/lessons/contexts/ctxkey"
+)
+
+var (
+	spanKey   = ctxkey.New[*Span]("tracing.span")
+	remoteKey = ctxkey.New[SpanContext]("tracing.remote")
+)
+
+// SpanData is the snapshot of the finished span passed to sinks
+type SpanData struct {
+	Name       string            `json:"name"`
+	TraceID    string            `json:"trace_id"`
+	SpanID     string            `json:"span_id"`
+	ParentID   string            `json:"parent_id,omitempty"`
+	Remote     bool              `json:"remote_parent,omitempty"`
+	Start      time.Time         `json:"start"`
+	End        time.Time         `json:"end"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+func (d SpanData) Duration() time.Duration {
+	return d.End.Sub(d.Start)
+}
+
+type Span struct {
+	tracer       *Tracer
+	name         string
+	context      SpanContext
+	parent       SpanID
+	remoteParent bool
+	start        time.Time
+
+	mutex      sync.Mutex
+	attributes map[string]string
+	ended      bool
+}
+
+func (s *Span) Context() SpanContext {
+	return s.context
+}
+
+func (s *Span) SetAttribute(key, value string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.attributes == nil {
+		s.attributes = make(map[string]string)
+	}
+
+	s.attributes[key] = value
+}
+
+// End exports the span if it is sampled, next calls do nothing
+func (s *Span) End() {
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+
+	s.ended = true
+	data := SpanData{
+		Name:       s.name,
+		TraceID:    s.context.TraceID.String(),
+		SpanID:     s.context.SpanID.String(),
+		Remote:     s.remoteParent,
+		Start:      s.start,
+		End:        time.Now(),
+		Attributes: maps.Clone(s.attributes),
+	}
+	s.mutex.Unlock()
+
+	if s.parent.IsValid() {
+		data.ParentID = s.parent.String()
+	}
+
+	if s.context.Flags.Sampled() {
+		s.tracer.sink.Export(data)
+	}
+}
+
+type Tracer struct {
+	sink Sink
+}
+
+func NewTracer(sink Sink) *Tracer {
+	return &Tracer{sink: sink}
+}
+
+func newSpanID() SpanID {
+	for {
+		var id SpanID
+		bits := rand.Uint64()
+		for i := range id {
+			id[i] = byte(bits >> (8 * i))
+		}
+
+		if id.IsValid() {
+			return id
+		}
+	}
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	first, second := newSpanID(), newSpanID()
+	copy(id[:8], first[:])
+	copy(id[8:], second[:])
+	return id
+}
+
+// Start creates the child of the span from ctx or of the remote span
+// extracted from headers, a new trace is started if there are none
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	span := &Span{
+		tracer: t,
+		name:   name,
+		start:  time.Now(),
+	}
+
+	parent, ok := SpanContextFromContext(ctx)
+	if ok {
+		span.context = parent
+		span.parent = parent.SpanID
+		span.remoteParent = parent.Remote
+	} else {
+		span.context = SpanContext{TraceID: newTraceID(), Flags: FlagSampled}
+	}
+
+	span.context.SpanID = newSpanID()
+	span.context.Remote = false
+	return ctxkey.WithValue(ctx, spanKey, span), span
+}
+
+// SpanFromContext returns nil if there is no span in ctx
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := spanKey.Value(ctx)
+	return span
+}
+
+// SpanContextFromContext prefers the local span to the remote one
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.context, true
+	}
+
+	return remoteKey.Value(ctx)
+}
+
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	sc.Remote = true
+	return ctxkey.WithValue(ctx, remoteKey, sc)
+}
@@ -0,0 +1,271 @@
+package tracing
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// W3C Trace Context: https://www.w3.org/TR/trace-context/
+
+var (
+	ErrInvalidTraceparent = errors.New("tracing: invalid traceparent")
+	ErrInvalidTracestate  = errors.New("tracing: invalid tracestate")
+)
+
+const (
+	traceparentHeader = "traceparent"
+	tracestateHeader  = "tracestate"
+
+	traceparentLength  = 55
+	maxTracestateItems = 32
+)
+
+type TraceID [16]byte
+
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+type SpanID [8]byte
+
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+type TraceFlags byte
+
+const FlagSampled TraceFlags = 0x01
+
+func (f TraceFlags) Sampled() bool {
+	return f&FlagSampled != 0
+}
+
+// SpanContext is the part of the span propagated between services
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Flags   TraceFlags
+	State   TraceState
+	Remote  bool // received from another service
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+func (sc SpanContext) Traceparent() string {
+	var b strings.Builder
+	b.Grow(traceparentLength)
+	b.WriteString("00-")
+	b.WriteString(sc.TraceID.String())
+	b.WriteByte('-')
+	b.WriteString(sc.SpanID.String())
+	b.WriteByte('-')
+	b.WriteString(hex.EncodeToString([]byte{byte(sc.Flags)}))
+	return b.String()
+}
+
+func decodeHex(dst []byte, src string) bool {
+	for i := 0; i < len(src); i++ {
+		if c := src[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false // uppercase isn't allowed
+		}
+	}
+
+	_, err := hex.Decode(dst, []byte(src))
+	return err == nil
+}
+
+// ParseTraceparent accepts future versions if their prefix is compatible with version 00
+func ParseTraceparent(value string) (SpanContext, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < traceparentLength {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	var version [1]byte
+	if !decodeHex(version[:], value[:2]) || version[0] == 0xff {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	if version[0] == 0 && len(value) != traceparentLength {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	if len(value) > traceparentLength && value[traceparentLength] != '-' {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	if value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	var sc SpanContext
+	var flags [1]byte
+	if !decodeHex(sc.TraceID[:], value[3:35]) ||
+		!decodeHex(sc.SpanID[:], value[36:52]) ||
+		!decodeHex(flags[:], value[53:55]) {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	sc.Flags = TraceFlags(flags[0])
+	sc.Remote = true
+	return sc, nil
+}
+
+type member struct {
+	key   string
+	value string
+}
+
+// TraceState is immutable, changes return a new state
+// with the changed member moved to the front
+type TraceState struct {
+	members []member
+}
+
+func validKey(key string) bool {
+	tenant, system, multiTenant := strings.Cut(key, "@")
+	if !multiTenant {
+		return validKeyPart(key, 256, false)
+	}
+
+	// tenant ids may start with a digit, system ids may not
+	return validKeyPart(tenant, 241, true) && validKeyPart(system, 14, false)
+}
+
+func validKeyPart(part string, maxLen int, digitFirst bool) bool {
+	if part == "" || len(part) > maxLen {
+		return false
+	}
+
+	for i := 0; i < len(part); i++ {
+		c := part[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9':
+			if i == 0 && !digitFirst {
+				return false
+			}
+		case i > 0 && (c == '_' || c == '-' || c == '*' || c == '/'):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func validValue(value string) bool {
+	if value == "" || len(value) > 256 || value[len(value)-1] == ' ' {
+		return false
+	}
+
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; c < 0x20 || c > 0x7e || c == ',' || c == '=' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseTracestate returns the empty state with the error if any member is invalid
+func ParseTracestate(value string) (TraceState, error) {
+	var state TraceState
+	for item := range strings.SplitSeq(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		key, val, found := strings.Cut(item, "=")
+		if !found || !validKey(key) || !validValue(val) {
+			return TraceState{}, ErrInvalidTracestate
+		}
+		if _, duplicate := state.Get(key); duplicate {
+			return TraceState{}, ErrInvalidTracestate
+		}
+
+		state.members = append(state.members, member{key: key, value: val})
+	}
+
+	if len(state.members) > maxTracestateItems {
+		return TraceState{}, ErrInvalidTracestate
+	}
+
+	return state, nil
+}
+
+func (ts TraceState) Get(key string) (string, bool) {
+	for _, m := range ts.members {
+		if m.key == key {
+			return m.value, true
+		}
+	}
+
+	return "", false
+}
+
+func (ts TraceState) Len() int {
+	return len(ts.members)
+}
+
+// Set moves the member to the front, the last member
+// is dropped if there are too many of them
+func (ts TraceState) Set(key, value string) (TraceState, error) {
+	if !validKey(key) || !validValue(value) {
+		return ts, ErrInvalidTracestate
+	}
+
+	members := make([]member, 0, len(ts.members)+1)
+	members = append(members, member{key: key, value: value})
+	for _, m := range ts.members {
+		if m.key != key {
+			members = append(members, m)
+		}
+	}
+
+	if len(members) > maxTracestateItems {
+		members = members[:maxTracestateItems]
+	}
+
+	return TraceState{members: members}, nil
+}
+
+func (ts TraceState) Delete(key string) TraceState {
+	members := make([]member, 0, len(ts.members))
+	for _, m := range ts.members {
+		if m.key != key {
+			members = append(members, m)
+		}
+	}
+
+	return TraceState{members: members}
+}
+
+func (ts TraceState) String() string {
+	var b strings.Builder
+	for i, m := range ts.members {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		b.WriteString(m.key)
+		b.WriteByte('=')
+		b.WriteString(m.value)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	const valid = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	sc, err := ParseTraceparent(valid)
+	assert.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	assert.True(t, sc.Flags.Sampled())
+	assert.True(t, sc.Remote)
+	assert.Equal(t, valid, sc.Traceparent())
+
+	sc, err = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future")
+	assert.NoError(t, err)
+	assert.False(t, sc.Flags.Sampled())
+
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01x",
+	}
+
+	for _, value := range invalid {
+		_, err = ParseTraceparent(value)
+		assert.ErrorIs(t, err, ErrInvalidTraceparent, value)
+	}
+}
+
+func TestTracestate(t *testing.T) {
+	state, err := ParseTracestate("rojo=00f067aa0ba902b7, congo=t61rcWkgMzE,tenant@vendor=value ")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, state.Len())
+	assert.Equal(t, "rojo=00f067aa0ba902b7,congo=t61rcWkgMzE,tenant@vendor=value", state.String())
+
+	value, ok := state.Get("congo")
+	assert.True(t, ok)
+	assert.Equal(t, "t61rcWkgMzE", value)
+
+	updated, err := state.Set("congo", "new")
+	assert.NoError(t, err)
+	assert.Equal(t, "congo=new,rojo=00f067aa0ba902b7,tenant@vendor=value", updated.String())
+	assert.Equal(t, "rojo=00f067aa0ba902b7,tenant@vendor=value", updated.Delete("congo").String())
+	assert.Equal(t, 3, state.Len()) // the state is immutable
+
+	_, err = state.Set("Upper", "value")
+	assert.ErrorIs(t, err, ErrInvalidTracestate)
+	_, err = state.Set("key", "a=b")
+	assert.ErrorIs(t, err, ErrInvalidTracestate)
+
+	for _, value := range []string{"key", "key=", "=value", "a=1,a=2", "@vendor=1", "key=a\tb", "1key=v", "a@b@c=v", "tenant@1vendor=v"} {
+		_, err = ParseTracestate(value)
+		assert.ErrorIs(t, err, ErrInvalidTracestate, value)
+	}
+
+	state, err = ParseTracestate("1tenant@vendor=v")
+	assert.NoError(t, err)
+	assert.Equal(t, "1tenant@vendor=v", state.String())
+
+	for i := 0; i < maxTracestateItems+5; i++ {
+		state, _ = state.Set("key"+strconv.Itoa(i), "value")
+	}
+	assert.Equal(t, maxTracestateItems, state.Len())
+}
@@ -0,0 +1,135 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpans(t *testing.T) {
+	recorder := NewRecorder()
+	tracer := NewTracer(recorder)
+
+	assert.Nil(t, SpanFromContext(context.Background()))
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	assert.Same(t, root, SpanFromContext(ctx))
+
+	childCtx, child := tracer.Start(ctx, "child")
+	child.SetAttribute("key", "value")
+	_, grandchild := tracer.Start(childCtx, "grandchild")
+
+	grandchild.End()
+	child.End()
+	child.End()
+	root.End()
+
+	spans := recorder.Spans()
+	assert.Len(t, spans, 3)
+	assert.Equal(t, []string{"grandchild", "child", "root"}, []string{spans[0].Name, spans[1].Name, spans[2].Name})
+	assert.Equal(t, spans[2].TraceID, spans[0].TraceID)
+	assert.Equal(t, spans[2].TraceID, spans[1].TraceID)
+	assert.Empty(t, spans[2].ParentID)
+	assert.Equal(t, spans[2].SpanID, spans[1].ParentID)
+	assert.Equal(t, spans[1].SpanID, spans[0].ParentID)
+	assert.Equal(t, map[string]string{"key": "value"}, spans[1].Attributes)
+	assert.GreaterOrEqual(t, spans[2].Duration(), spans[1].Duration())
+
+	_, other := tracer.Start(context.Background(), "other")
+	assert.NotEqual(t, root.Context().TraceID, other.Context().TraceID)
+
+	recorder.Reset()
+	assert.Empty(t, recorder.Spans())
+}
+
+func TestNotSampled(t *testing.T) {
+	recorder := NewRecorder()
+	tracer := NewTracer(recorder)
+
+	sc, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	_, span := tracer.Start(ContextWithRemoteSpanContext(context.Background(), sc), "span")
+	span.End()
+
+	assert.Empty(t, recorder.Spans())
+}
+
+func TestPropagation(t *testing.T) {
+	recorder := NewRecorder()
+	tracer := NewTracer(recorder)
+
+	var received http.Header
+	server := httptest.NewServer(tracer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		assert.NotNil(t, SpanFromContext(r.Context()))
+		w.WriteHeader(http.StatusTeapot)
+	})))
+	defer server.Close()
+
+	sc, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	sc.State, _ = ParseTracestate("rojo=00f067aa0ba902b7")
+	ctx, root := tracer.Start(ContextWithRemoteSpanContext(context.Background(), sc), "root")
+
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/welcome", nil)
+	response, err := tracer.Client(nil).Do(request)
+	assert.NoError(t, err)
+	_ = response.Body.Close()
+	assert.Empty(t, request.Header) // the request isn't changed
+	root.End()
+
+	spans := recorder.Spans()
+	assert.Len(t, spans, 3)
+	serverSpan, clientSpan, rootSpan := spans[0], spans[1], spans[2]
+
+	assert.Equal(t, "GET /welcome", serverSpan.Name)
+	assert.Equal(t, "HTTP GET", clientSpan.Name)
+	for _, span := range spans {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.TraceID)
+	}
+
+	assert.Equal(t, "00f067aa0ba902b7", rootSpan.ParentID)
+	assert.True(t, rootSpan.Remote)
+	assert.Equal(t, rootSpan.SpanID, clientSpan.ParentID)
+	assert.Equal(t, clientSpan.SpanID, serverSpan.ParentID)
+	assert.True(t, serverSpan.Remote)
+	assert.Equal(t, "418", serverSpan.Attributes["http.status_code"])
+	assert.Equal(t, "418", clientSpan.Attributes["http.status_code"])
+
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+clientSpan.SpanID+"-01", received.Get("traceparent"))
+	assert.Equal(t, "rojo=00f067aa0ba902b7", received.Get("tracestate"))
+}
+
+func TestExtractInvalidHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("traceparent", "invalid")
+	header.Set("tracestate", "rojo=00f067aa0ba902b7")
+
+	ctx := Extract(context.Background(), header)
+	_, ok := SpanContextFromContext(ctx)
+	assert.False(t, ok)
+
+	header = http.Header{}
+	Inject(ctx, header)
+	assert.Empty(t, header)
+}
+
+func TestJSONSink(t *testing.T) {
+	var buffer bytes.Buffer
+	tracer := NewTracer(NewJSONSink(&buffer))
+
+	_, span := tracer.Start(context.Background(), "span")
+	span.SetAttribute("key", "value")
+	span.End()
+
+	var data SpanData
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &data))
+	assert.Equal(t, "span", data.Name)
+	assert.Equal(t, span.Context().TraceID.String(), data.TraceID)
+	assert.Equal(t, span.Context().SpanID.String(), data.SpanID)
+	assert.Equal(t, map[string]string{"key": "value"}, data.Attributes)
+	assert.NotContains(t, buffer.String(), "parent_id")
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var ErrNoCases = errors.New("selector has no cases")
+
+// CaseID identifies a case added to the selector
+type CaseID int
+
+type Result[T any] struct {
+	ID     CaseID
+	Value  T    // received value
+	Sent   bool // the send case fired
+	Closed bool // the channel was closed and the case is removed
+}
+
+type entry struct {
+	id     CaseID
+	weight int
+	c      reflect.SelectCase
+}
+
+// Selector is a typed wrapper over reflect.Select with the set of cases
+// changed at runtime, reflect.Select chooses uniformly between ready cases,
+// so a case with weight N is repeated N times to be chosen N times more often,
+// Selector isn't safe for concurrent use
+type Selector[T any] struct {
+	entries []entry
+	nextID  CaseID
+
+	// entries repeated by weights and one slot for
+	// ctx.Done or default, rebuilt after changes
+	cases  []reflect.SelectCase
+	owners []int // index in entries for every case
+	dirty  bool
+}
+
+func NewSelector[T any]() *Selector[T] {
+	return &Selector[T]{}
+}
+
+func (s *Selector[T]) add(weight int, c reflect.SelectCase) CaseID {
+	if weight <= 0 {
+		panic("dynamic_select: weight must be positive")
+	}
+
+	s.nextID++
+	s.entries = append(s.entries, entry{id: s.nextID, weight: weight, c: c})
+	s.dirty = true
+	return s.nextID
+}
+
+func (s *Selector[T]) AddRecv(ch <-chan T, weight int) CaseID {
+	return s.add(weight, reflect.SelectCase{
+		Dir:  reflect.SelectRecv,
+		Chan: reflect.ValueOf(ch),
+	})
+}
+
+// AddSend adds the case sending value every time it fires,
+// the channel mustn't be closed while the case is added
+func (s *Selector[T]) AddSend(ch chan<- T, value T, weight int) CaseID {
+	return s.add(weight, reflect.SelectCase{
+		Dir:  reflect.SelectSend,
+		Chan: reflect.ValueOf(ch),
+		Send: reflect.ValueOf(&value).Elem(), // keeps the type of nil interfaces
+	})
+}
+
+func (s *Selector[T]) Remove(id CaseID) bool {
+	for i := range s.entries {
+		if s.entries[i].id == id {
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+			s.dirty = true
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Selector[T]) Len() int {
+	return len(s.entries)
+}
+
+func (s *Selector[T]) build() {
+	if !s.dirty {
+		return
+	}
+
+	s.cases = s.cases[:0]
+	s.owners = s.owners[:0]
+	for i, e := range s.entries {
+		for range e.weight {
+			s.cases = append(s.cases, e.c)
+			s.owners = append(s.owners, i)
+		}
+	}
+
+	s.cases = append(s.cases, reflect.SelectCase{})
+	s.dirty = false
+}
+
+func (s *Selector[T]) selectWith(last reflect.SelectCase) (int, Result[T]) {
+	s.build()
+	s.cases[len(s.cases)-1] = last
+	chosen, received, ok := reflect.Select(s.cases)
+	s.cases[len(s.cases)-1] = reflect.SelectCase{} // don't keep ctx alive
+	if chosen == len(s.cases)-1 {
+		return chosen, Result[T]{}
+	}
+
+	e := s.entries[s.owners[chosen]]
+	if e.c.Dir == reflect.SelectSend {
+		return chosen, Result[T]{ID: e.id, Sent: true}
+	}
+
+	if !ok {
+		s.Remove(e.id)
+		return chosen, Result[T]{ID: e.id, Closed: true}
+	}
+
+	value, _ := received.Interface().(T) // nil interfaces aren't converted
+	return chosen, Result[T]{ID: e.id, Value: value}
+}
+
+// Select blocks until one of the cases fires or ctx is done
+func (s *Selector[T]) Select(ctx context.Context) (Result[T], error) {
+	if len(s.entries) == 0 {
+		return Result[T]{}, ErrNoCases
+	}
+
+	last := reflect.SelectCase{Dir: reflect.SelectRecv}
+	if done := ctx.Done(); done != nil {
+		last.Chan = reflect.ValueOf(done)
+	} // a zero Chan is never ready like the nil channel
+
+	chosen, result := s.selectWith(last)
+	if chosen == len(s.cases)-1 {
+		return Result[T]{}, ctx.Err()
+	}
+
+	return result, nil
+}
+
+// TrySelect returns false if none of the cases is ready
+func (s *Selector[T]) TrySelect() (Result[T], bool) {
+	if len(s.entries) == 0 {
+		return Result[T]{}, false
+	}
+
+	chosen, result := s.selectWith(reflect.SelectCase{Dir: reflect.SelectDefault})
+	return result, chosen != len(s.cases)-1
+}
+
+func producer(ch chan<- int, value int, count int) {
+	defer close(ch)
+	for range count {
+		ch <- value
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func main() {
+	ch1 := make(chan int) // more prioritized
+	ch2 := make(chan int)
+
+	go producer(ch1, 1, 5)
+	go producer(ch2, 2, 5)
+
+	selector := NewSelector[int]()
+	selector.AddRecv(ch1, 3)
+	selector.AddRecv(ch2, 1)
+
+	for selector.Len() != 0 {
+		result, _ := selector.Select(context.Background())
+		if result.Closed {
+			fmt.Printf("case %d closed\n", result.ID)
+			continue
+		}
+
+		fmt.Printf("case %d: %d\n", result.ID, result.Value)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -race -v .
+
+func TestSelector(t *testing.T) {
+	selector := NewSelector[int]()
+	_, err := selector.Select(context.Background())
+	assert.ErrorIs(t, err, ErrNoCases)
+
+	first := make(chan int, 1)
+	second := make(chan int, 1)
+	firstID := selector.AddRecv(first, 1)
+	secondID := selector.AddRecv(second, 1)
+	assert.Equal(t, 2, selector.Len())
+
+	_, ready := selector.TrySelect()
+	assert.False(t, ready)
+
+	second <- 10
+	result, err := selector.Select(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, Result[int]{ID: secondID, Value: 10}, result)
+
+	assert.True(t, selector.Remove(secondID))
+	assert.False(t, selector.Remove(secondID))
+	second <- 20
+	_, ready = selector.TrySelect()
+	assert.False(t, ready)
+
+	first <- 30
+	result, ready = selector.TrySelect()
+	assert.True(t, ready)
+	assert.Equal(t, Result[int]{ID: firstID, Value: 30}, result)
+}
+
+func TestSelectorClosedChannel(t *testing.T) {
+	selector := NewSelector[int]()
+	ch := make(chan int, 1)
+	ch <- 1
+	close(ch)
+	id := selector.AddRecv(ch, 1)
+
+	result, _ := selector.Select(context.Background())
+	assert.Equal(t, Result[int]{ID: id, Value: 1}, result)
+
+	result, _ = selector.Select(context.Background())
+	assert.Equal(t, Result[int]{ID: id, Closed: true}, result)
+	assert.Zero(t, selector.Len())
+}
+
+func TestSelectorSend(t *testing.T) {
+	selector := NewSelector[error]()
+	ch := make(chan error, 2)
+	id := selector.AddSend(ch, nil, 1)
+
+	for range 2 {
+		result, err := selector.Select(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, Result[error]{ID: id, Sent: true}, result)
+	}
+
+	assert.Len(t, ch, 2)
+	assert.Nil(t, <-ch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	<-ch
+	ch <- nil
+	ch <- nil
+	_, err := selector.Select(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestSelectorWeights(t *testing.T) {
+	const iterations = 10000
+
+	selector := NewSelector[struct{}]()
+	heavy := make(chan struct{}, 1)
+	light := make(chan struct{}, 1)
+	heavyID := selector.AddRecv(heavy, 3)
+	selector.AddRecv(light, 1)
+
+	heavyCount, lightCount := 0.0, 0.0
+	for range iterations {
+		heavy <- struct{}{}
+		light <- struct{}{}
+
+		result, _ := selector.Select(context.Background())
+		if result.ID == heavyID {
+			heavyCount++
+			<-light
+		} else {
+			lightCount++
+			<-heavy
+		}
+	}
+
+	assert.InDelta(t, 3, heavyCount/lightCount, 0.5)
+}
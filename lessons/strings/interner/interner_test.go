@@ -0,0 +1,143 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unique"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -v .
+// go test -bench=. .
+
+// cleanups are executed in the background after GC
+func waitForCollection(t *testing.T, interner *Interner, entries int) {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); {
+		runtime.GC()
+		if interner.Stats().Entries == entries {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("expected %d entries, got %d", entries, interner.Stats().Entries)
+}
+
+func TestInterner(t *testing.T) {
+	interner := NewInterner()
+
+	big := strings.Repeat("x", 100) + "label"
+	handle1 := interner.Make(big[100:])
+	handle2 := interner.Make(strings.Clone("label"))
+	handle3 := interner.Make("other")
+
+	assert.Equal(t, handle1, handle2)
+	assert.NotEqual(t, handle1, handle3)
+	assert.Equal(t, "label", handle1.Value())
+	assert.Same(t, unsafe.StringData(handle1.Value()), unsafe.StringData(handle2.Value()))
+	assert.NotSame(t, unsafe.StringData(big[100:]), unsafe.StringData(handle1.Value()))
+
+	stats := interner.Stats()
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, 10, stats.Bytes)
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(2), stats.Misses)
+	assert.Equal(t, int64(5), stats.SavedBytes)
+
+	runtime.KeepAlive(handle1)
+	runtime.KeepAlive(handle3)
+}
+
+func TestInternerWeakEntries(t *testing.T) {
+	interner := NewInterner()
+
+	handle := interner.Make("alive")
+	_ = interner.Make("collected")
+
+	waitForCollection(t, interner, 1)
+	assert.Equal(t, handle, interner.Make("alive"))
+
+	// collected strings can be interned again
+	assert.Equal(t, "collected", interner.Intern("collected"))
+}
+
+func TestInternerCanonicalStringKeepsEntry(t *testing.T) {
+	interner := NewInterner()
+
+	s1 := interner.Intern(strings.Clone("label"))
+	runtime.GC()
+	s2 := interner.Intern(strings.Clone("label"))
+
+	assert.Same(t, unsafe.StringData(s1), unsafe.StringData(s2))
+
+	stats := interner.Stats()
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.Equal(t, int64(5), stats.SavedBytes)
+}
+
+func TestInternerEmptyString(t *testing.T) {
+	interner := NewInterner()
+
+	assert.Equal(t, "", Handle{}.Value())
+	assert.Equal(t, Handle{}, interner.Make(""))
+	assert.Equal(t, "", interner.Intern(""))
+	assert.Zero(t, interner.Stats().Entries)
+}
+
+func TestPool(t *testing.T) {
+	interner := NewInterner()
+	pool := interner.NewPool()
+
+	for i := 0; i < 100; i++ {
+		_ = pool.Intern([]string{"a", "b", "c"}[i%3])
+	}
+
+	assert.Equal(t, 3, pool.Len())
+	runtime.GC()
+	assert.Equal(t, 3, interner.Stats().Entries)
+
+	pool.Drop()
+	assert.Zero(t, pool.Len())
+	waitForCollection(t, interner, 0)
+}
+
+func TestInternerConcurrentAccess(t *testing.T) {
+	interner := NewInterner()
+
+	wg := sync.WaitGroup{}
+	wg.Add(8)
+	for i := 0; i < 8; i++ {
+		go func() {
+			defer wg.Done()
+			pool := interner.NewPool()
+			for j := 0; j < 1000; j++ {
+				value := strings.Repeat("x", j%10)
+				assert.Equal(t, value, pool.Intern(value))
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+var labels = []string{"service", "method", "status", "region", "host"}
+
+func BenchmarkInterner(b *testing.B) {
+	interner := NewInterner()
+	pool := interner.NewPool()
+	for i := 0; i < b.N; i++ {
+		_ = pool.Make(labels[i%len(labels)])
+	}
+}
+
+func BenchmarkUnique(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = unique.Make(labels[i%len(labels)])
+	}
+}
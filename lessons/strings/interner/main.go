@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"hash/maphash"
+	"runtime"
+	"sync"
+	"time"
+	"unsafe"
+	"weak"
+)
+
+// smaller objects without pointers share blocks of the tiny allocator,
+// so they would be collected only together with their neighbours
+const minAllocation = 16
+
+// Handle is like unique.Handle: handles of equal strings are
+// equal and comparing them takes O(1), while at least one handle
+// of a string is alive the interner keeps its canonical copy
+type Handle struct {
+	data *byte
+	len  int
+}
+
+func (h Handle) Value() string {
+	if h.data == nil {
+		return ""
+	}
+
+	return unsafe.String(h.data, h.len)
+}
+
+type Stats struct {
+	Entries    int   // alive canonical strings
+	Bytes      int   // size of alive canonical strings
+	Hits       int64 // strings that were already interned
+	Misses     int64
+	SavedBytes int64 // size of duplicates replaced by canonical strings
+}
+
+// weakString points weakly to the data of the canonical string,
+// so the canonical string itself keeps the entry alive
+type weakString struct {
+	data weak.Pointer[byte]
+	len  int
+}
+
+func (w weakString) value() (string, bool) {
+	data := w.data.Value()
+	if data == nil {
+		return "", false
+	}
+
+	return unsafe.String(data, w.len), true
+}
+
+// Interner holds canonical strings weakly, so strings
+// which aren't referenced are collected by the garbage collector,
+// entries are grouped by hashes to not hold the strings by map keys
+type Interner struct {
+	mutex   sync.Mutex
+	seed    maphash.Seed
+	entries map[uint64][]weakString
+	stats   Stats
+}
+
+func NewInterner() *Interner {
+	return &Interner{
+		seed:    maphash.MakeSeed(),
+		entries: make(map[uint64][]weakString),
+	}
+}
+
+func (i *Interner) Make(value string) Handle {
+	if value == "" {
+		return Handle{}
+	}
+
+	hash := maphash.String(i.seed, value)
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	for _, w := range i.entries[hash] {
+		if canonical, alive := w.value(); alive && canonical == value {
+			i.stats.Hits++
+			i.stats.SavedBytes += int64(len(value))
+			return Handle{data: unsafe.StringData(canonical), len: len(canonical)}
+		}
+	}
+
+	i.stats.Misses++
+
+	// copy to not hold a bigger string if value is a substring
+	buffer := make([]byte, max(len(value), minAllocation))
+	copy(buffer, value)
+	data := &buffer[0]
+
+	runtime.AddCleanup(data, i.delete, hash)
+	i.entries[hash] = append(i.entries[hash], weakString{data: weak.Make(data), len: len(value)})
+	return Handle{data: data, len: len(value)}
+}
+
+// Intern returns the canonical copy of the string, it is kept
+// while the returned string, handles or pools reference it
+func (i *Interner) Intern(value string) string {
+	return i.Make(value).Value()
+}
+
+func (i *Interner) delete(hash uint64) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	// the string could be interned again before the cleanup
+	alive := i.entries[hash][:0]
+	for _, w := range i.entries[hash] {
+		if _, ok := w.value(); ok {
+			alive = append(alive, w)
+		}
+	}
+
+	if len(alive) == 0 {
+		delete(i.entries, hash)
+	} else {
+		clear(i.entries[hash][len(alive):])
+		i.entries[hash] = alive
+	}
+}
+
+func (i *Interner) Stats() Stats {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	stats := i.stats
+	for _, bucket := range i.entries {
+		for _, w := range bucket {
+			if _, alive := w.value(); alive {
+				stats.Entries++
+				stats.Bytes += w.len
+			}
+		}
+	}
+
+	return stats
+}
+
+// Pool keeps strings interned within a scope (a request, a batch, etc.)
+// alive until Drop, after that they can be collected
+type Pool struct {
+	interner *Interner
+	mutex    sync.Mutex
+	handles  map[Handle]struct{}
+}
+
+func (i *Interner) NewPool() *Pool {
+	return &Pool{
+		interner: i,
+		handles:  make(map[Handle]struct{}),
+	}
+}
+
+func (p *Pool) Make(value string) Handle {
+	handle := p.interner.Make(value)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.handles[handle] = struct{}{}
+	return handle
+}
+
+func (p *Pool) Intern(value string) string {
+	return p.Make(value).Value()
+}
+
+func (p *Pool) Len() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return len(p.handles)
+}
+
+func (p *Pool) Drop() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	clear(p.handles)
+}
+
+func main() {
+	interner := NewInterner()
+	pool := interner.NewPool()
+
+	labels := make([]string, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		labels = append(labels, pool.Intern(fmt.Sprint("label_", i%10)))
+	}
+
+	fmt.Printf("%+v\n", interner.Stats())
+
+	pool.Drop()
+	labels = nil
+
+	runtime.GC()
+	time.Sleep(100 * time.Millisecond) // cleanups are executed in the background
+	fmt.Printf("%+v\n", interner.Stats())
+}
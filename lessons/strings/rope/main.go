@@ -0,0 +1,341 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// maximal size of a leaf in bytes, small leaves are merged
+// during concatenation to keep the tree shallow
+const maxLeaf = 1024
+
+// nodes are immutable, so they can be shared by many ropes
+type node struct {
+	left   *node
+	right  *node
+	text   string // only in leaves
+	bytes  int
+	runes  int
+	height int
+}
+
+func (n *node) leaf() bool {
+	return n.left == nil && n.right == nil
+}
+
+func height(n *node) int {
+	if n == nil {
+		return -1
+	}
+
+	return n.height
+}
+
+func newLeaf(text string) *node {
+	if text == "" {
+		return nil
+	}
+
+	return &node{
+		text:  text,
+		bytes: len(text),
+		runes: utf8.RuneCountInString(text),
+	}
+}
+
+func newInner(left, right *node) *node {
+	return &node{
+		left:   left,
+		right:  right,
+		bytes:  left.bytes + right.bytes,
+		runes:  left.runes + right.runes,
+		height: 1 + max(left.height, right.height),
+	}
+}
+
+func rotateLeft(n *node) *node {
+	return newInner(newInner(n.left, n.right.left), n.right.right)
+}
+
+func rotateRight(n *node) *node {
+	return newInner(n.left.left, newInner(n.left.right, n.right))
+}
+
+// rebalance restores AVL invariant when heights of children differ by two
+func rebalance(n *node) *node {
+	switch diff := height(n.left) - height(n.right); {
+	case diff > 1:
+		if height(n.left.left) < height(n.left.right) {
+			n = newInner(rotateLeft(n.left), n.right)
+		}
+		return rotateRight(n)
+	case diff < -1:
+		if height(n.right.right) < height(n.right.left) {
+			n = newInner(n.left, rotateRight(n.right))
+		}
+		return rotateLeft(n)
+	default:
+		return n
+	}
+}
+
+func concat(left, right *node) *node {
+	switch {
+	case left == nil:
+		return right
+	case right == nil:
+		return left
+	case left.leaf() && right.leaf() && left.bytes+right.bytes <= maxLeaf:
+		return newLeaf(left.text + right.text)
+	case left.height > right.height+1:
+		return rebalance(newInner(left.left, concat(left.right, right)))
+	case right.height > left.height+1:
+		return rebalance(newInner(concat(left, right.left), right.right))
+	default:
+		return newInner(left, right)
+	}
+}
+
+// byteOffset converts position in runes to position in bytes
+func byteOffset(text string, position int) int {
+	for offset := range text {
+		if position == 0 {
+			return offset
+		}
+		position--
+	}
+
+	return len(text)
+}
+
+// split divides the tree into runes [0, position) and [position, runes)
+func split(n *node, position int) (*node, *node) {
+	switch {
+	case n == nil || position <= 0:
+		return nil, n
+	case position >= n.runes:
+		return n, nil
+	case n.leaf():
+		offset := byteOffset(n.text, position)
+		return newLeaf(n.text[:offset]), newLeaf(n.text[offset:])
+	case position < n.left.runes:
+		left, right := split(n.left, position)
+		return left, concat(right, n.right)
+	default:
+		left, right := split(n.right, position-n.left.runes)
+		return concat(n.left, left), right
+	}
+}
+
+func build(leaves []*node) *node {
+	switch len(leaves) {
+	case 0:
+		return nil
+	case 1:
+		return leaves[0]
+	default:
+		middle := len(leaves) / 2
+		return newInner(build(leaves[:middle]), build(leaves[middle:]))
+	}
+}
+
+func buildFromString(text string) *node {
+	var leaves []*node
+	for len(text) > maxLeaf {
+		end := maxLeaf
+		for !utf8.RuneStart(text[end]) {
+			end--
+		}
+
+		leaves = append(leaves, newLeaf(text[:end]))
+		text = text[end:]
+	}
+
+	if text != "" {
+		leaves = append(leaves, newLeaf(text))
+	}
+
+	return build(leaves)
+}
+
+func (n *node) walk(yield func(string) bool) bool {
+	switch {
+	case n == nil:
+		return true
+	case n.leaf():
+		return yield(n.text)
+	default:
+		return n.left.walk(yield) && n.right.walk(yield)
+	}
+}
+
+// Rope is a text for frequent edits in the middle, all positions are
+// in runes, edits take O(log n) and don't change clones of the rope
+type Rope struct {
+	root *node
+}
+
+func NewRope(text string) Rope {
+	return Rope{root: buildFromString(text)}
+}
+
+func (r *Rope) check(from, to int) {
+	if from < 0 || from > to || to > r.Len() {
+		panic("rope: position out of range")
+	}
+}
+
+// Len returns the number of runes
+func (r *Rope) Len() int {
+	if r.root == nil {
+		return 0
+	}
+
+	return r.root.runes
+}
+
+// Size returns the number of bytes
+func (r *Rope) Size() int {
+	if r.root == nil {
+		return 0
+	}
+
+	return r.root.bytes
+}
+
+// Clone takes O(1) because nodes are never changed
+func (r *Rope) Clone() Rope {
+	return Rope{root: r.root}
+}
+
+func (r *Rope) Insert(position int, text string) {
+	r.check(position, position)
+	left, right := split(r.root, position)
+	r.root = concat(concat(left, buildFromString(text)), right)
+}
+
+// Delete removes runes in [from, to)
+func (r *Rope) Delete(from, to int) {
+	r.check(from, to)
+	left, rest := split(r.root, from)
+	_, right := split(rest, to-from)
+	r.root = concat(left, right)
+}
+
+// Slice returns runes in [from, to) sharing nodes with the rope
+func (r *Rope) Slice(from, to int) Rope {
+	r.check(from, to)
+	_, rest := split(r.root, from)
+	middle, _ := split(rest, to-from)
+	return Rope{root: middle}
+}
+
+// Index returns the rune at the position
+func (r *Rope) Index(position int) rune {
+	if position < 0 || position >= r.Len() {
+		panic("rope: position out of range")
+	}
+
+	n := r.root
+	for !n.leaf() {
+		if position < n.left.runes {
+			n = n.left
+		} else {
+			position -= n.left.runes
+			n = n.right
+		}
+	}
+
+	value, _ := utf8.DecodeRuneInString(n.text[byteOffset(n.text, position):])
+	return value
+}
+
+func (r *Rope) String() string {
+	var builder strings.Builder
+	builder.Grow(r.Size())
+	r.root.walk(func(text string) bool {
+		builder.WriteString(text)
+		return true
+	})
+
+	return builder.String()
+}
+
+// WriteTo implements io.WriterTo without building the whole string
+func (r *Rope) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	var err error
+	r.root.walk(func(text string) bool {
+		var n int
+		n, err = io.WriteString(w, text)
+		written += int64(n)
+		return err == nil
+	})
+
+	return written, err
+}
+
+func (r *Rope) NewReader() *Reader {
+	reader := &Reader{}
+	reader.push(r.root)
+	return reader
+}
+
+// Reader reads a snapshot of the rope taken at creation
+type Reader struct {
+	stack   []*node // right subtrees to visit
+	current string
+}
+
+func (r *Reader) push(n *node) {
+	for n != nil && !n.leaf() {
+		r.stack = append(r.stack, n.right)
+		n = n.left
+	}
+
+	if n != nil {
+		r.current = n.text
+	}
+}
+
+func (r *Reader) Read(buffer []byte) (int, error) {
+	var read int
+	for read < len(buffer) {
+		if r.current == "" {
+			if len(r.stack) == 0 {
+				break
+			}
+
+			next := r.stack[len(r.stack)-1]
+			r.stack = r.stack[:len(r.stack)-1]
+			r.push(next)
+			continue
+		}
+
+		n := copy(buffer[read:], r.current)
+		r.current = r.current[n:]
+		read += n
+	}
+
+	if read == 0 && len(buffer) != 0 {
+		return 0, io.EOF
+	}
+
+	return read, nil
+}
+
+func main() {
+	text := NewRope("Hello world")
+	text.Insert(5, ", привет")
+	snapshot := text.Clone()
+	text.Delete(0, 7)
+
+	fmt.Println(snapshot.String())
+	fmt.Println(text.String(), string(text.Index(0)))
+
+	_, _ = io.Copy(os.Stdout, text.NewReader())
+	fmt.Println()
+}
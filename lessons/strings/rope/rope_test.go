@@ -0,0 +1,128 @@
+package main
+
+import (
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -v .
+
+func checkBalance(t *testing.T, n *node) {
+	if n == nil || n.leaf() {
+		return
+	}
+
+	assert.LessOrEqual(t, abs(height(n.left)-height(n.right)), 1)
+	assert.Equal(t, n.left.runes+n.right.runes, n.runes)
+	checkBalance(t, n.left)
+	checkBalance(t, n.right)
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
+
+func TestRope(t *testing.T) {
+	text := NewRope("мир")
+	assert.Equal(t, 3, text.Len())
+	assert.Equal(t, 6, text.Size())
+
+	text.Insert(0, "привет ")
+	text.Insert(text.Len(), "!")
+	assert.Equal(t, "привет мир!", text.String())
+	assert.Equal(t, 'м', text.Index(7))
+
+	clone := text.Clone()
+	text.Delete(6, 10)
+	assert.Equal(t, "привет!", text.String())
+	assert.Equal(t, "привет мир!", clone.String())
+
+	part := clone.Slice(7, 10)
+	assert.Equal(t, "мир", part.String())
+
+	assert.Panics(t, func() { text.Delete(5, 100) })
+	assert.Panics(t, func() { text.Index(text.Len()) })
+
+	var empty Rope
+	assert.Equal(t, "", empty.String())
+	empty.Insert(0, "a")
+	assert.Equal(t, "a", empty.String())
+}
+
+func TestRopeRandomEdits(t *testing.T) {
+	alphabet := []rune("abcdefgh абвгд 世界")
+	randomText := func(random *rand.Rand, length int) string {
+		runes := make([]rune, length)
+		for idx := range runes {
+			runes[idx] = alphabet[random.Intn(len(alphabet))]
+		}
+		return string(runes)
+	}
+
+	random := rand.New(rand.NewSource(42))
+	expected := []rune(randomText(random, 10000))
+	text := NewRope(string(expected))
+
+	for i := 0; i < 1000; i++ {
+		from := random.Intn(len(expected) + 1)
+		if random.Intn(2) == 0 {
+			inserted := randomText(random, random.Intn(2*maxLeaf))
+			text.Insert(from, inserted)
+			expected = append(expected[:from], append([]rune(inserted), expected[from:]...)...)
+		} else {
+			to := from + random.Intn(len(expected)-from+1)
+			text.Delete(from, to)
+			expected = append(expected[:from], expected[to:]...)
+		}
+	}
+
+	checkBalance(t, text.root)
+	assert.Equal(t, len(expected), text.Len())
+	assert.Equal(t, string(expected), text.String())
+
+	for i := 0; i < 100; i++ {
+		position := random.Intn(len(expected))
+		assert.Equal(t, expected[position], text.Index(position))
+	}
+}
+
+func TestRopeReaderAndWriterTo(t *testing.T) {
+	source := strings.Repeat("0123456789абв", 1000)
+	text := NewRope(source)
+	text.Insert(5000, "inserted")
+	expected := text.String()
+
+	data, err := io.ReadAll(text.NewReader())
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(data))
+
+	var builder strings.Builder
+	written, err := text.WriteTo(&builder)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(expected)), written)
+	assert.Equal(t, expected, builder.String())
+}
+
+func BenchmarkRopeInsert(b *testing.B) {
+	text := NewRope(strings.Repeat("a", 1<<22))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		text.Insert(text.Len()/2, "b")
+	}
+}
+
+func BenchmarkStringInsert(b *testing.B) {
+	text := strings.Repeat("a", 1<<22)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		text = text[:len(text)/2] + "b" + text[len(text)/2:]
+	}
+}
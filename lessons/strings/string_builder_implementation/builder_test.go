@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -v .
+
+var _ io.Writer = (*Builder)(nil)
+var _ io.StringWriter = (*Builder)(nil)
+var _ io.ByteWriter = (*Builder)(nil)
+
+func TestBuilder(t *testing.T) {
+	var builder Builder
+	assert.Equal(t, "", builder.String())
+
+	_, _ = builder.Write([]byte("ab"))
+	_ = builder.WriteByte('c')
+	n, _ := builder.WriteRune('ж')
+	assert.Equal(t, 2, n)
+	_, _ = fmt.Fprintf(&builder, "%d", 42)
+
+	assert.Equal(t, "abcж42", builder.String())
+	assert.Equal(t, 7, builder.Len())
+
+	symbol, found := builder.At(1)
+	assert.True(t, found)
+	assert.Equal(t, byte('b'), symbol)
+	_, found = builder.At(7)
+	assert.False(t, found)
+
+	builder.Reset()
+	assert.Zero(t, builder.Len())
+	assert.Zero(t, builder.Cap())
+}
+
+func TestBuilderGrow(t *testing.T) {
+	var builder Builder
+	_, _ = builder.WriteString("abc")
+
+	builder.Grow(1) // never truncates
+	assert.Equal(t, "abc", builder.String())
+
+	builder.Grow(100)
+	capacity := builder.Cap()
+	assert.GreaterOrEqual(t, capacity, 103)
+
+	before := unsafe.StringData(builder.String())
+	for i := 0; i < 100; i++ {
+		_ = builder.WriteByte('x')
+	}
+
+	assert.Equal(t, capacity, builder.Cap())
+	assert.Same(t, before, unsafe.StringData(builder.String()))
+}
+
+func TestBuilderStringWithoutCopy(t *testing.T) {
+	var builder Builder
+	_, _ = builder.WriteString("abc")
+
+	str := builder.String()
+	assert.Same(t, unsafe.SliceData(builder.buffer), unsafe.StringData(str))
+
+	for i := 0; i < 1000; i++ {
+		_ = builder.WriteByte('x')
+	}
+
+	assert.Equal(t, "abc", str)
+}
+
+func TestBuilderCopyCheck(t *testing.T) {
+	var builder Builder
+	_ = builder.WriteByte('a')
+
+	copied := builder
+	assert.Panics(t, func() { _ = copied.WriteByte('b') })
+
+	// zero builders can be copied
+	empty := NewBuilder()
+	assert.NotPanics(t, func() { _ = empty.WriteByte('b') })
+}
+
+func BenchmarkBuilder(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		var builder Builder
+		for j := 0; j < 1000; j++ {
+			_ = builder.WriteByte('x')
+		}
+		_ = builder.String()
+	}
+}
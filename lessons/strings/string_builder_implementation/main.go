@@ -1,8 +1,15 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"unicode/utf8"
+	"unsafe"
+)
 
+// Builder is append-only: bytes that are already written are never
+// changed, so String can return them without copying
 type Builder struct {
+	addr   *Builder // detects copies by value, they would share the buffer
 	buffer []byte
 }
 
@@ -10,44 +17,96 @@ func NewBuilder() Builder {
 	return Builder{}
 }
 
-func (b *Builder) Grow(capacity int) {
-	if capacity < 0 {
-		return
+func (b *Builder) copyCheck() {
+	if b.addr == nil {
+		b.addr = b
+	} else if b.addr != b {
+		panic("builder: illegal use of non-zero Builder copied by value")
 	}
+}
+
+// grow reallocates the buffer, strings returned
+// by String keep pointing to the old one
+func (b *Builder) grow(n int) {
+	buffer := make([]byte, len(b.buffer), 2*cap(b.buffer)+n)
+	copy(buffer, b.buffer)
+	b.buffer = buffer
+}
 
-	if capacity < len(b.buffer) {
-		b.buffer = b.buffer[:capacity]
+// Grow guarantees space for another n bytes without allocations
+func (b *Builder) Grow(n int) {
+	b.copyCheck()
+	if n < 0 {
 		return
 	}
 
-	buffer := make([]byte, len(b.buffer), capacity)
-	copy(buffer, b.buffer)
-	b.buffer = buffer
+	if cap(b.buffer)-len(b.buffer) < n {
+		b.grow(n)
+	}
+}
+
+func (b *Builder) Write(data []byte) (int, error) {
+	b.copyCheck()
+	b.buffer = append(b.buffer, data...)
+	return len(data), nil
 }
 
-func (b *Builder) Write(symbol byte) {
+func (b *Builder) WriteString(data string) (int, error) {
+	b.copyCheck()
+	b.buffer = append(b.buffer, data...)
+	return len(data), nil
+}
+
+func (b *Builder) WriteByte(symbol byte) error {
+	b.copyCheck()
 	b.buffer = append(b.buffer, symbol)
+	return nil
 }
 
-func (b *Builder) At(index int) *byte {
+// WriteRune writes the UTF-8 encoding of the rune
+func (b *Builder) WriteRune(symbol rune) (int, error) {
+	b.copyCheck()
+	length := len(b.buffer)
+	b.buffer = utf8.AppendRune(b.buffer, symbol)
+	return len(b.buffer) - length, nil
+}
+
+// At returns a copy of the byte, a pointer would allow
+// to change strings that were returned by String
+func (b *Builder) At(index int) (byte, bool) {
 	if index < 0 || index >= len(b.buffer) {
-		return nil
+		return 0, false
 	}
 
-	return &b.buffer[index]
+	return b.buffer[index], true
+}
+
+func (b *Builder) Len() int {
+	return len(b.buffer)
+}
+
+func (b *Builder) Cap() int {
+	return cap(b.buffer)
+}
+
+// Reset drops the buffer instead of reusing it,
+// because it can be shared with returned strings
+func (b *Builder) Reset() {
+	b.addr = nil
+	b.buffer = nil
 }
 
 func (b *Builder) String() string {
-	return string(b.buffer)
+	return unsafe.String(unsafe.SliceData(b.buffer), len(b.buffer))
 }
 
 func main() {
 	builder := NewBuilder()
 	builder.Grow(3)
 
-	builder.Write('a')
-	builder.Write('b')
-	builder.Write('c')
+	_ = builder.WriteByte('a')
+	_, _ = builder.WriteString("bc")
+	_, _ = builder.WriteRune('я')
 
-	fmt.Println(builder.String())
+	fmt.Println(builder.String(), builder.Len(), builder.Cap())
 }
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Lazy skip list: writers lock only the predecessors of the changed node,
+// readers and iterators don't take locks at all and never see
+// half-linked or removed nodes
+
+const maxLevel = 24
+
+type node[K cmp.Ordered, V comparable] struct {
+	key         K
+	value       atomic.Pointer[V]
+	next        []atomic.Pointer[node[K, V]]
+	mutex       sync.Mutex
+	marked      atomic.Bool // logically removed
+	fullyLinked atomic.Bool // linked on all levels
+}
+
+func newNode[K cmp.Ordered, V comparable](key K, value V, topLevel int) *node[K, V] {
+	n := &node[K, V]{
+		key:  key,
+		next: make([]atomic.Pointer[node[K, V]], topLevel+1),
+	}
+
+	n.value.Store(&value)
+	return n
+}
+
+func (n *node[K, V]) topLevel() int {
+	return len(n.next) - 1
+}
+
+func (n *node[K, V]) alive() bool {
+	return n.fullyLinked.Load() && !n.marked.Load()
+}
+
+type SkipList[K cmp.Ordered, V comparable] struct {
+	head *node[K, V] // sentinel, nil is used as the tail
+	size atomic.Int64
+}
+
+func NewSkipList[K cmp.Ordered, V comparable]() *SkipList[K, V] {
+	return &SkipList[K, V]{
+		head: &node[K, V]{next: make([]atomic.Pointer[node[K, V]], maxLevel)},
+	}
+}
+
+func randomLevel() int {
+	level := 0
+	for level < maxLevel-1 && rand.IntN(4) == 0 {
+		level++
+	}
+
+	return level
+}
+
+// find fills predecessors and successors of key on every level
+// and returns the highest level where key was found or -1
+func (s *SkipList[K, V]) find(key K, preds, succs *[maxLevel]*node[K, V]) int {
+	found := -1
+	pred := s.head
+	for level := maxLevel - 1; level >= 0; level-- {
+		curr := pred.next[level].Load()
+		for curr != nil && curr.key < key {
+			pred, curr = curr, curr.next[level].Load()
+		}
+
+		if found == -1 && curr != nil && curr.key == key {
+			found = level
+		}
+
+		preds[level] = pred
+		succs[level] = curr
+	}
+
+	return found
+}
+
+// lockPredecessors locks distinct predecessors from the bottom level and checks
+// that nothing changed between them and succs since find, returns the unlock function,
+// successors are allowed to be marked only when they are being removed by the caller
+func lockPredecessors[K cmp.Ordered, V comparable](topLevel int, preds, succs *[maxLevel]*node[K, V], removing bool) (bool, func()) {
+	var locked []*node[K, V]
+	unlock := func() {
+		for _, pred := range locked {
+			pred.mutex.Unlock()
+		}
+	}
+
+	for level := 0; level <= topLevel; level++ {
+		pred, succ := preds[level], succs[level]
+		if len(locked) == 0 || locked[len(locked)-1] != pred {
+			pred.mutex.Lock()
+			locked = append(locked, pred)
+		}
+
+		if pred.marked.Load() || (!removing && succ != nil && succ.marked.Load()) || pred.next[level].Load() != succ {
+			return false, unlock
+		}
+	}
+
+	return true, unlock
+}
+
+func (s *SkipList[K, V]) Load(key K) (V, bool) {
+	var preds, succs [maxLevel]*node[K, V]
+	if level := s.find(key, &preds, &succs); level != -1 && succs[level].alive() {
+		return *succs[level].value.Load(), true
+	}
+
+	var zero V
+	return zero, false
+}
+
+func (s *SkipList[K, V]) Store(key K, value V) {
+	s.insert(key, value, true)
+}
+
+// LoadOrStore returns the existing value for the key if present,
+// otherwise it stores and returns the given value
+func (s *SkipList[K, V]) LoadOrStore(key K, value V) (V, bool) {
+	return s.insert(key, value, false)
+}
+
+func (s *SkipList[K, V]) insert(key K, value V, overwrite bool) (V, bool) {
+	topLevel := randomLevel()
+	var preds, succs [maxLevel]*node[K, V]
+	for {
+		if level := s.find(key, &preds, &succs); level != -1 {
+			found := succs[level]
+			if found.marked.Load() {
+				continue // wait until it is unlinked
+			}
+
+			for !found.fullyLinked.Load() {
+				runtime.Gosched()
+			}
+
+			if overwrite {
+				found.value.Store(&value)
+				return value, true
+			}
+
+			return *found.value.Load(), true
+		}
+
+		valid, unlock := lockPredecessors(topLevel, &preds, &succs, false)
+		if !valid {
+			unlock()
+			continue
+		}
+
+		n := newNode(key, value, topLevel)
+		for level := 0; level <= topLevel; level++ {
+			n.next[level].Store(succs[level])
+		}
+		for level := 0; level <= topLevel; level++ {
+			preds[level].next[level].Store(n)
+		}
+
+		n.fullyLinked.Store(true)
+		unlock()
+
+		s.size.Add(1)
+		return value, false
+	}
+}
+
+// CompareAndSwap swaps the value for the key if the current value is equal to old
+func (s *SkipList[K, V]) CompareAndSwap(key K, old, new V) bool {
+	var preds, succs [maxLevel]*node[K, V]
+	level := s.find(key, &preds, &succs)
+	if level == -1 || !succs[level].alive() {
+		return false
+	}
+
+	found := succs[level]
+	for {
+		current := found.value.Load()
+		if *current != old {
+			return false
+		}
+
+		if found.value.CompareAndSwap(current, &new) {
+			return true
+		}
+	}
+}
+
+func (s *SkipList[K, V]) Delete(key K) bool {
+	var preds, succs [maxLevel]*node[K, V]
+	var victim *node[K, V]
+	for {
+		level := s.find(key, &preds, &succs)
+		if victim == nil {
+			if level == -1 {
+				return false
+			}
+
+			candidate := succs[level]
+			if !candidate.alive() || candidate.topLevel() != level {
+				return false
+			}
+
+			candidate.mutex.Lock()
+			if candidate.marked.Load() {
+				candidate.mutex.Unlock()
+				return false
+			}
+
+			candidate.marked.Store(true)
+			victim = candidate
+		}
+
+		for level := 0; level <= victim.topLevel(); level++ {
+			succs[level] = victim
+		}
+
+		valid, unlock := lockPredecessors(victim.topLevel(), &preds, &succs, true)
+		if !valid {
+			unlock()
+			continue
+		}
+
+		for level := victim.topLevel(); level >= 0; level-- {
+			preds[level].next[level].Store(victim.next[level].Load())
+		}
+
+		victim.mutex.Unlock()
+		unlock()
+
+		s.size.Add(-1)
+		return true
+	}
+}
+
+func (s *SkipList[K, V]) Len() int {
+	return int(s.size.Load())
+}
+
+func (s *SkipList[K, V]) ascend(from *node[K, V], stop func(K) bool, yield func(K, V) bool) {
+	for curr := from; curr != nil && !stop(curr.key); curr = curr.next[0].Load() {
+		if curr.alive() && !yield(curr.key, *curr.value.Load()) {
+			return
+		}
+	}
+}
+
+// All iterates over entries in ascending order of keys, it is safe to change
+// the list during iteration, entries changed concurrently may be seen or not
+func (s *SkipList[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		s.ascend(s.head.next[0].Load(), func(K) bool { return false }, yield)
+	}
+}
+
+// Range iterates over entries with keys in [from, to) like All
+func (s *SkipList[K, V]) Range(from, to K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		var preds, succs [maxLevel]*node[K, V]
+		s.find(from, &preds, &succs)
+		s.ascend(succs[0], func(key K) bool { return key >= to }, yield)
+	}
+}
+
+func main() {
+	list := NewSkipList[int, string]()
+
+	wg := sync.WaitGroup{}
+	wg.Add(10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			defer wg.Done()
+			list.Store(i, fmt.Sprint("value_", i))
+		}()
+	}
+
+	wg.Wait()
+
+	list.Delete(5)
+	list.CompareAndSwap(3, "value_3", "new_value_3")
+	for key, value := range list.Range(2, 8) {
+		fmt.Println(key, value)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"math/rand/v2"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -race -v .
+// go test -bench=. .
+
+func TestSkipList(t *testing.T) {
+	list := NewSkipList[int, string]()
+	assert.Zero(t, list.Len())
+
+	list.Store(10, "a")
+	list.Store(5, "b")
+	list.Store(15, "c")
+	list.Store(10, "d")
+
+	value, found := list.Load(10)
+	assert.True(t, found)
+	assert.Equal(t, "d", value)
+	_, found = list.Load(7)
+	assert.False(t, found)
+	assert.Equal(t, 3, list.Len())
+
+	value, loaded := list.LoadOrStore(5, "e")
+	assert.True(t, loaded)
+	assert.Equal(t, "b", value)
+	value, loaded = list.LoadOrStore(7, "e")
+	assert.False(t, loaded)
+	assert.Equal(t, "e", value)
+
+	assert.False(t, list.CompareAndSwap(7, "x", "y"))
+	assert.True(t, list.CompareAndSwap(7, "e", "f"))
+	assert.False(t, list.CompareAndSwap(8, "e", "f"))
+	value, _ = list.Load(7)
+	assert.Equal(t, "f", value)
+
+	assert.True(t, list.Delete(10))
+	assert.False(t, list.Delete(10))
+	assert.Equal(t, 3, list.Len())
+
+	var keys []int
+	for key := range list.All() {
+		keys = append(keys, key)
+	}
+	assert.Equal(t, []int{5, 7, 15}, keys)
+
+	keys = nil
+	for key := range list.Range(6, 15) {
+		keys = append(keys, key)
+	}
+	assert.Equal(t, []int{7}, keys)
+}
+
+func TestSkipListConcurrentAccess(t *testing.T) {
+	const goroutines = 8
+	const keys = 1000
+
+	list := NewSkipList[int, int]()
+
+	wg := sync.WaitGroup{}
+	wg.Add(goroutines * 2)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for key := 0; key < keys; key++ {
+				list.Store(key, key)
+				if key%2 == 1 {
+					list.Delete(key)
+				}
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			previous := -1
+			for key, value := range list.All() {
+				assert.Less(t, previous, key)
+				assert.Equal(t, key, value)
+				previous = key
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, keys/2, list.Len())
+	for key := range list.All() {
+		assert.Zero(t, key%2)
+	}
+}
+
+func TestSkipListConcurrentIncrement(t *testing.T) {
+	const goroutines = 8
+	const increments = 1000
+
+	list := NewSkipList[string, int]()
+
+	wg := sync.WaitGroup{}
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < increments; j++ {
+				for {
+					value, _ := list.LoadOrStore("counter", 0)
+					if list.CompareAndSwap("counter", value, value+1) {
+						break
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	value, _ := list.Load("counter")
+	assert.Equal(t, goroutines*increments, value)
+}
+
+type RWMutexMap struct {
+	mu sync.RWMutex
+	m  map[int]int
+}
+
+func (c *RWMutexMap) Load(key int) (int, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	value, found := c.m[key]
+	return value, found
+}
+
+func (c *RWMutexMap) Store(key int, value int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.m[key] = value
+}
+
+type Storage interface {
+	Load(int) (int, bool)
+	Store(int, int)
+}
+
+const benchmarkKeys = 1 << 16
+
+// every tenth operation is a write
+func benchmarkStorage(b *testing.B, storage Storage) {
+	for key := 0; key < benchmarkKeys; key++ {
+		storage.Store(key, key)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			key := rand.IntN(benchmarkKeys)
+			if i%10 == 0 {
+				storage.Store(key, i)
+			} else {
+				storage.Load(key)
+			}
+		}
+	})
+}
+
+func BenchmarkRWMutexMap(b *testing.B) {
+	benchmarkStorage(b, &RWMutexMap{m: make(map[int]int)})
+}
+
+func BenchmarkSkipList(b *testing.B) {
+	benchmarkStorage(b, NewSkipList[int, int]())
+}
@@ -0,0 +1,20 @@
+package main
+
+import "sync"
+
+// release and debuglock builds have to provide the same methods
+var (
+	_ interface {
+		sync.Locker
+		TryLock() bool
+	} = (*Mutex)(nil)
+
+	_ interface {
+		sync.Locker
+		TryLock() bool
+		RLock()
+		RUnlock()
+		TryRLock() bool
+		RLocker() sync.Locker
+	} = (*RWMutex)(nil)
+)
@@ -0,0 +1,268 @@
+//go:build debuglock
+
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	ErrRecursiveLocking   = errors.New("recursive locking")
+	ErrLockOrderInversion = errors.New("lock order inversion")
+)
+
+// OnProblem is called before a goroutine blocks on a lock,
+// so problems are reported even if it deadlocks after that
+var OnProblem = func(err error) {
+	panic(err)
+}
+
+type heldLock struct {
+	lock  any
+	site  string
+	since time.Time
+}
+
+type detector struct {
+	mutex sync.Mutex
+	held  map[int64][]heldLock   // by goroutine id
+	edges map[any]map[any]string // edges[a][b] is a site where b was locked while a was held
+	stats map[string]*SiteStats  // by call site
+}
+
+var locks = detector{
+	held:  make(map[int64][]heldLock),
+	edges: make(map[any]map[any]string),
+	stats: make(map[string]*SiteStats),
+}
+
+// goroutine id is not exported by the runtime, but
+// it is the second word of the goroutine stack header
+func goroutineID() int64 {
+	var buffer [64]byte
+	header := buffer[:runtime.Stack(buffer[:], false)]
+	header = bytes.TrimPrefix(header, []byte("goroutine "))
+	header = header[:bytes.IndexByte(header, ' ')]
+	id, _ := strconv.ParseInt(string(header), 10, 64)
+	return id
+}
+
+// caller returns the call site of Lock or RLock
+func caller() string {
+	_, file, line, _ := runtime.Caller(3)
+	return fmt.Sprintf("%s:%d", filepath.Base(file), line)
+}
+
+// path returns sites of the lock order chain from -> ... -> to
+func (d *detector) path(from, to any, visited map[any]bool) []string {
+	if from == to {
+		return []string{}
+	}
+
+	visited[from] = true
+	for next, site := range d.edges[from] {
+		if visited[next] {
+			continue
+		}
+
+		if sites := d.path(next, to, visited); sites != nil {
+			return append([]string{site}, sites...)
+		}
+	}
+
+	return nil
+}
+
+// order records that lock is taken while locks of the goroutine are held
+// and returns problems found, d.mutex has to be held
+func (d *detector) order(id int64, lock any, site string) []error {
+	var problems []error
+	for _, held := range d.held[id] {
+		if held.lock == lock {
+			problems = append(problems, fmt.Errorf("%w: %s, the lock is held by the goroutine since %s", ErrRecursiveLocking, site, held.site))
+			continue
+		}
+
+		if sites := d.path(lock, held.lock, map[any]bool{}); sites != nil {
+			problems = append(problems, fmt.Errorf("%w: %s while holding lock from %s, reversed order at %v", ErrLockOrderInversion, site, held.site, sites))
+		}
+
+		if d.edges[held.lock] == nil {
+			d.edges[held.lock] = make(map[any]string)
+		}
+		d.edges[held.lock][lock] = site
+	}
+
+	return problems
+}
+
+// acquired makes lock held by the goroutine, d.mutex has to be held
+func (d *detector) acquired(id int64, lock any, site string, wait time.Duration) {
+	d.held[id] = append(d.held[id], heldLock{lock: lock, site: site, since: time.Now()})
+
+	stats := d.stats[site]
+	if stats == nil {
+		stats = &SiteStats{Site: site}
+		d.stats[site] = stats
+	}
+
+	stats.Acquisitions++
+	stats.Wait += wait
+	stats.MaxWait = max(stats.MaxWait, wait)
+}
+
+func (d *detector) lock(lock any, acquire func()) {
+	site := caller()
+	id := goroutineID()
+
+	d.mutex.Lock()
+	problems := d.order(id, lock, site)
+	d.mutex.Unlock()
+
+	for _, problem := range problems {
+		OnProblem(problem)
+	}
+
+	begin := time.Now()
+	acquire()
+	wait := time.Since(begin)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.acquired(id, lock, site, wait)
+}
+
+// tryLock doesn't report problems, a failed attempt doesn't block,
+// but the order is still recorded on success
+func (d *detector) tryLock(lock any, try func() bool) bool {
+	site := caller()
+	id := goroutineID()
+	if !try() {
+		return false
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.order(id, lock, site)
+	d.acquired(id, lock, site, 0)
+	return true
+}
+
+func (d *detector) unlock(lock any) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	// a lock can be released by another goroutine, so look through all of them
+	ids := append([]int64{goroutineID()}, slices.Collect(maps.Keys(d.held))...)
+	for _, id := range ids {
+		held := d.held[id]
+		for idx := len(held) - 1; idx >= 0; idx-- {
+			if held[idx].lock != lock {
+				continue
+			}
+
+			stats := d.stats[held[idx].site]
+			hold := time.Since(held[idx].since)
+			stats.Hold += hold
+			stats.MaxHold = max(stats.MaxHold, hold)
+
+			d.held[id] = append(held[:idx], held[idx+1:]...)
+			if len(d.held[id]) == 0 {
+				delete(d.held, id)
+			}
+			return
+		}
+	}
+}
+
+// Contention returns statistics of call sites sorted by total wait time
+func Contention() []SiteStats {
+	locks.mutex.Lock()
+	defer locks.mutex.Unlock()
+
+	result := make([]SiteStats, 0, len(locks.stats))
+	for _, stats := range locks.stats {
+		result = append(result, *stats)
+	}
+
+	slices.SortFunc(result, func(lhs, rhs SiteStats) int {
+		return cmp.Compare(rhs.Wait, lhs.Wait)
+	})
+
+	return result
+}
+
+type Mutex struct {
+	mutex sync.Mutex
+}
+
+func (m *Mutex) Lock() {
+	locks.lock(m, m.mutex.Lock)
+}
+
+func (m *Mutex) TryLock() bool {
+	return locks.tryLock(m, m.mutex.TryLock)
+}
+
+func (m *Mutex) Unlock() {
+	locks.unlock(m)
+	m.mutex.Unlock()
+}
+
+type RWMutex struct {
+	mutex sync.RWMutex
+}
+
+func (m *RWMutex) Lock() {
+	locks.lock(m, m.mutex.Lock)
+}
+
+func (m *RWMutex) TryLock() bool {
+	return locks.tryLock(m, m.mutex.TryLock)
+}
+
+func (m *RWMutex) Unlock() {
+	locks.unlock(m)
+	m.mutex.Unlock()
+}
+
+// RLock is checked like Lock: recursive read locking
+// deadlocks when a writer is waiting between two RLock calls
+func (m *RWMutex) RLock() {
+	locks.lock(m, m.mutex.RLock)
+}
+
+func (m *RWMutex) RUnlock() {
+	locks.unlock(m)
+	m.mutex.RUnlock()
+}
+
+func (m *RWMutex) TryRLock() bool {
+	return locks.tryLock(m, m.mutex.TryRLock)
+}
+
+type rlocker RWMutex
+
+func (r *rlocker) Lock() {
+	locks.lock((*RWMutex)(r), r.mutex.RLock)
+}
+
+func (r *rlocker) Unlock() {
+	(*RWMutex)(r).RUnlock()
+}
+
+// RLocker reports the call site of Lock like RLock does
+func (m *RWMutex) RLocker() sync.Locker {
+	return (*rlocker)(m)
+}
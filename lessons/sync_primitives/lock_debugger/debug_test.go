@@ -0,0 +1,141 @@
+//go:build debuglock
+
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -tags debuglock -v .
+
+func captureProblems(t *testing.T) *[]error {
+	var problems []error
+	previous := OnProblem
+	OnProblem = func(err error) {
+		problems = append(problems, err)
+	}
+
+	t.Cleanup(func() { OnProblem = previous })
+	return &problems
+}
+
+func TestRecursiveLocking(t *testing.T) {
+	problems := captureProblems(t)
+
+	var mutex RWMutex
+	mutex.RLock()
+	assert.Empty(t, *problems)
+
+	mutex.RLock() // doesn't block without writers
+	assert.Len(t, *problems, 1)
+	assert.ErrorIs(t, (*problems)[0], ErrRecursiveLocking)
+
+	mutex.RUnlock()
+	mutex.RUnlock()
+}
+
+func TestRecursiveLockingPanics(t *testing.T) {
+	cache := Cache{data: map[string]string{}}
+	defer func() {
+		err, _ := recover().(error)
+		assert.ErrorIs(t, err, ErrRecursiveLocking)
+		assert.Contains(t, err.Error(), "main.go")
+
+		cache.mutex.Lock() // Get released the lock during panic
+		cache.mutex.Unlock()
+	}()
+
+	cache.Get("key")
+}
+
+func TestLockOrderInversion(t *testing.T) {
+	problems := captureProblems(t)
+
+	var mutex1, mutex2, mutex3 Mutex
+	normalizeResources(&mutex1, &mutex2)
+	normalizeResources(&mutex2, &mutex3)
+	assert.Empty(t, *problems)
+
+	normalizeResources(&mutex3, &mutex1) // cycle through three locks
+	assert.Len(t, *problems, 1)
+	assert.ErrorIs(t, (*problems)[0], ErrLockOrderInversion)
+}
+
+func TestUnlockInAnotherGoroutine(t *testing.T) {
+	problems := captureProblems(t)
+
+	var mutex Mutex
+	mutex.Lock()
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		mutex.Unlock()
+	}()
+
+	wg.Wait()
+	mutex.Lock()
+	mutex.Unlock()
+	assert.Empty(t, *problems)
+}
+
+func TestContention(t *testing.T) {
+	var mutex Mutex
+
+	locked := make(chan struct{})
+	go func() {
+		mutex.Lock()
+		close(locked)
+		time.Sleep(100 * time.Millisecond)
+		mutex.Unlock()
+	}()
+
+	<-locked
+	mutex.Lock() // waits for 100ms
+	mutex.Unlock()
+
+	stats := Contention()
+	assert.NotEmpty(t, stats)
+	assert.GreaterOrEqual(t, stats[0].Wait, 100*time.Millisecond)
+	assert.Contains(t, stats[0].Site, "debug_test.go")
+}
+
+func TestTryLock(t *testing.T) {
+	problems := captureProblems(t)
+
+	var mutex1 Mutex
+	var mutex2 RWMutex
+
+	assert.True(t, mutex1.TryLock())
+	assert.False(t, mutex1.TryLock()) // failed attempts aren't problems
+	assert.True(t, mutex2.TryRLock())
+	assert.False(t, mutex2.TryLock())
+	mutex2.RUnlock()
+	mutex1.Unlock()
+	assert.Empty(t, *problems)
+
+	// the order mutex1 -> mutex2 is recorded by TryLock
+	mutex2.Lock()
+	mutex1.Lock()
+	mutex1.Unlock()
+	mutex2.Unlock()
+	assert.Len(t, *problems, 1)
+	assert.ErrorIs(t, (*problems)[0], ErrLockOrderInversion)
+
+	locker := mutex2.RLocker()
+	locker.Lock()
+	assert.True(t, mutex2.TryRLock()) // recursive read locking by TryRLock isn't reported
+	mutex2.RUnlock()
+	locker.Lock()
+	locker.Unlock()
+	locker.Unlock()
+
+	assert.Len(t, *problems, 2)
+	assert.ErrorIs(t, (*problems)[1], ErrRecursiveLocking)
+	assert.Contains(t, (*problems)[1].Error(), "debug_test.go")
+}
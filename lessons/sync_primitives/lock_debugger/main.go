@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+)
+
+// go run -tags debuglock .
+
+type Cache struct {
+	mutex Mutex
+	data  map[string]string
+}
+
+func (c *Cache) Get(key string) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.Size() > 0 { // recursive locking
+		return c.data[key]
+	}
+
+	return ""
+}
+
+func (c *Cache) Size() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return len(c.data)
+}
+
+func normalizeResources(lhs, rhs *Mutex) {
+	lhs.Lock()
+	rhs.Lock()
+
+	// normalization
+
+	rhs.Unlock()
+	lhs.Unlock()
+}
+
+func main() {
+	var mutex1 Mutex
+	var mutex2 Mutex
+
+	normalizeResources(&mutex1, &mutex2)
+	for _, stats := range Contention() {
+		fmt.Printf("%+v\n", stats)
+	}
+
+	// sequential calls don't deadlock, but the detector
+	// reports that concurrent ones can
+	normalizeResources(&mutex2, &mutex1)
+
+	cache := Cache{data: map[string]string{"key": "value"}}
+	fmt.Println(cache.Get("key"))
+}
@@ -0,0 +1,18 @@
+//go:build !debuglock
+
+package main
+
+import "sync"
+
+type Mutex struct {
+	sync.Mutex
+}
+
+type RWMutex struct {
+	sync.RWMutex
+}
+
+// Contention is collected only with -tags debuglock
+func Contention() []SiteStats {
+	return nil
+}
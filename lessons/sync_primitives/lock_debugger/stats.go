@@ -0,0 +1,13 @@
+package main
+
+import "time"
+
+// SiteStats describes acquisitions of locks at one call site
+type SiteStats struct {
+	Site         string
+	Acquisitions int
+	Wait         time.Duration
+	MaxWait      time.Duration
+	Hold         time.Duration
+	MaxHold      time.Duration
+}
@@ -0,0 +1,140 @@
+package semaphore
+
+import (
+	"context"
+	"sync"
+)
+
+type waiter struct {
+	weight int
+	ready  chan struct{} // closed when units are acquired
+}
+
+// Semaphore serves waiters in FIFO order, so a big request
+// at the head of the queue is not starved by small ones
+type Semaphore struct {
+	mutex   sync.Mutex
+	count   int
+	max     int
+	waiters []*waiter
+}
+
+func NewSemaphore(limit int) *Semaphore {
+	if limit < 0 {
+		panic("semaphore: negative limit")
+	}
+
+	return &Semaphore{
+		max: limit,
+	}
+}
+
+// Acquire blocks until weight units are available or ctx is done, requests
+// bigger than the limit wait for Resize. On failure it returns ctx.Err()
+// and leaves the semaphore unchanged
+func (s *Semaphore) Acquire(ctx context.Context, weight int) error {
+	if weight < 0 {
+		panic("semaphore: negative weight")
+	}
+
+	s.mutex.Lock()
+	if err := ctx.Err(); err != nil {
+		s.mutex.Unlock()
+		return err
+	}
+
+	if s.max-s.count >= weight && len(s.waiters) == 0 {
+		s.count += weight
+		s.mutex.Unlock()
+		return nil
+	}
+
+	w := &waiter{weight: weight, ready: make(chan struct{})}
+	s.waiters = append(s.waiters, w)
+	s.mutex.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	select {
+	case <-w.ready:
+		// units were acquired at the same time, give them back
+		s.count -= weight
+	default:
+		for idx, queued := range s.waiters {
+			if queued == w {
+				s.waiters = append(s.waiters[:idx], s.waiters[idx+1:]...)
+				break
+			}
+		}
+	}
+
+	s.notify() // the waiter could block the queue
+	return ctx.Err()
+}
+
+// TryAcquire acquires weight units only if it doesn't need to wait
+func (s *Semaphore) TryAcquire(weight int) bool {
+	if weight < 0 {
+		panic("semaphore: negative weight")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.max-s.count < weight || len(s.waiters) != 0 {
+		return false
+	}
+
+	s.count += weight
+	return true
+}
+
+func (s *Semaphore) Release(weight int) {
+	if weight < 0 {
+		panic("semaphore: negative weight")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if weight > s.count {
+		panic("semaphore: released more than held")
+	}
+
+	s.count -= weight
+	s.notify()
+}
+
+// Resize changes the limit, when it decreases below acquired
+// units new requests wait until enough units are released
+func (s *Semaphore) Resize(limit int) {
+	if limit < 0 {
+		panic("semaphore: negative limit")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.max = limit
+	s.notify()
+}
+
+func (s *Semaphore) notify() {
+	for len(s.waiters) != 0 {
+		w := s.waiters[0]
+		if s.max-s.count < w.weight {
+			return // don't let small requests overtake the head
+		}
+
+		s.count += w.weight
+		s.waiters = s.waiters[1:]
+		close(w.ready)
+	}
+}
@@ -0,0 +1,133 @@
+package semaphore
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -race -v .
+
+func TestSemaphore(t *testing.T) {
+	semaphore := NewSemaphore(3)
+	ctx := context.Background()
+
+	assert.NoError(t, semaphore.Acquire(ctx, 2))
+	assert.True(t, semaphore.TryAcquire(1))
+	assert.False(t, semaphore.TryAcquire(1))
+
+	semaphore.Release(3)
+	assert.True(t, semaphore.TryAcquire(3))
+	semaphore.Release(3)
+
+	assert.Panics(t, func() { semaphore.Release(1) })
+	assert.Panics(t, func() { semaphore.TryAcquire(-1) })
+}
+
+func TestSemaphoreCancellation(t *testing.T) {
+	semaphore := NewSemaphore(1)
+	assert.True(t, semaphore.TryAcquire(1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, semaphore.Acquire(ctx, 1), context.DeadlineExceeded)
+	assert.ErrorIs(t, semaphore.Acquire(ctx, 0), context.DeadlineExceeded)
+
+	semaphore.Release(1)
+	assert.True(t, semaphore.TryAcquire(1)) // the canceled waiter left the queue
+}
+
+func TestSemaphoreFairness(t *testing.T) {
+	semaphore := NewSemaphore(3)
+	ctx := context.Background()
+	assert.True(t, semaphore.TryAcquire(2))
+
+	var bigAcquired atomic.Bool
+	go func() {
+		assert.NoError(t, semaphore.Acquire(ctx, 3))
+		bigAcquired.Store(true)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	// the big request is waiting, so small ones must not overtake it
+	assert.False(t, semaphore.TryAcquire(1))
+
+	var smallAcquired atomic.Bool
+	go func() {
+		assert.NoError(t, semaphore.Acquire(ctx, 1))
+		smallAcquired.Store(true)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, smallAcquired.Load())
+
+	semaphore.Release(2)
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, bigAcquired.Load())
+	assert.False(t, smallAcquired.Load())
+
+	semaphore.Release(3)
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, smallAcquired.Load())
+}
+
+func TestSemaphoreResize(t *testing.T) {
+	semaphore := NewSemaphore(1)
+	assert.True(t, semaphore.TryAcquire(1))
+
+	var acquired atomic.Bool
+	go func() {
+		assert.NoError(t, semaphore.Acquire(context.Background(), 2))
+		acquired.Store(true)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, acquired.Load())
+
+	semaphore.Resize(3)
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, acquired.Load())
+
+	semaphore.Resize(1)
+	semaphore.Release(2)
+	assert.False(t, semaphore.TryAcquire(1)) // 1 unit is still acquired
+
+	semaphore.Release(1)
+	assert.True(t, semaphore.TryAcquire(1))
+
+	assert.Panics(t, func() { semaphore.Resize(-1) })
+	assert.Panics(t, func() { NewSemaphore(-1) })
+}
+
+func TestSemaphoreConcurrentAccess(t *testing.T) {
+	const limit = 3
+	semaphore := NewSemaphore(limit)
+
+	var current atomic.Int32
+	wg := sync.WaitGroup{}
+	wg.Add(20)
+	for i := 0; i < 20; i++ {
+		go func() {
+			defer wg.Done()
+			weight := i%limit + 1
+			for j := 0; j < 50; j++ {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+				if semaphore.Acquire(ctx, weight) == nil {
+					assert.LessOrEqual(t, current.Add(int32(weight)), int32(limit))
+					current.Add(-int32(weight))
+					semaphore.Release(weight)
+				}
+				cancel()
+			}
+		}()
+	}
+
+	wg.Wait()
+	assert.True(t, semaphore.TryAcquire(limit))
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"hash/maphash"
+	"sync"
+	"unsafe"
+)
+
+const cacheLineSize = 64
+
+type shard[K comparable, V any] struct {
+	mutex sync.RWMutex
+	data  map[K]V
+
+	// neighbour shards are locked by different goroutines,
+	// so they must not share a cache line (false sharing)
+	_ [cacheLineSize - (unsafe.Sizeof(sync.RWMutex{})+unsafe.Sizeof(map[int]int{}))%cacheLineSize]byte
+}
+
+type ShardedMap[K comparable, V any] struct {
+	seed   maphash.Seed
+	mask   uint64
+	shards []shard[K, V]
+}
+
+// NewShardedMap rounds the number of shards up to a power of two
+func NewShardedMap[K comparable, V any](shards int) *ShardedMap[K, V] {
+	count := 1
+	for count < shards {
+		count <<= 1
+	}
+
+	m := &ShardedMap[K, V]{
+		seed:   maphash.MakeSeed(),
+		mask:   uint64(count - 1),
+		shards: make([]shard[K, V], count),
+	}
+
+	for idx := range m.shards {
+		m.shards[idx].data = make(map[K]V)
+	}
+
+	return m
+}
+
+func (m *ShardedMap[K, V]) shard(key K) *shard[K, V] {
+	return &m.shards[maphash.Comparable(m.seed, key)&m.mask]
+}
+
+func (m *ShardedMap[K, V]) Load(key K) (V, bool) {
+	s := m.shard(key)
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	value, found := s.data[key]
+	return value, found
+}
+
+func (m *ShardedMap[K, V]) Store(key K, value V) {
+	s := m.shard(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.data[key] = value
+}
+
+// LoadOrStore returns the existing value for the key if present,
+// otherwise it stores and returns the given value
+func (m *ShardedMap[K, V]) LoadOrStore(key K, value V) (V, bool) {
+	s := m.shard(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if actual, found := s.data[key]; found {
+		return actual, true
+	}
+
+	s.data[key] = value
+	return value, false
+}
+
+func (m *ShardedMap[K, V]) Delete(key K) {
+	s := m.shard(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.data, key)
+}
+
+// Compute atomically replaces the value for the key with the result of action,
+// the key is deleted when action returns keep = false. The action is called
+// under the shard lock, so it must not use the map
+func (m *ShardedMap[K, V]) Compute(key K, action func(value V, loaded bool) (V, bool)) (V, bool) {
+	s := m.shard(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	old, loaded := s.data[key]
+	value, keep := action(old, loaded)
+	if !keep {
+		delete(s.data, key)
+		var zero V
+		return zero, false
+	}
+
+	s.data[key] = value
+	return value, true
+}
+
+func (m *ShardedMap[K, V]) Len() int {
+	var length int
+	for idx := range m.shards {
+		s := &m.shards[idx]
+		s.mutex.RLock()
+		length += len(s.data)
+		s.mutex.RUnlock()
+	}
+
+	return length
+}
+
+// Range calls action for entries of every shard until it returns false, entries
+// are copied before the call, so action can use the map without deadlocks
+func (m *ShardedMap[K, V]) Range(action func(K, V) bool) {
+	type entry struct {
+		key   K
+		value V
+	}
+
+	var entries []entry
+	for idx := range m.shards {
+		s := &m.shards[idx]
+		s.mutex.RLock()
+		entries = entries[:0]
+		for key, value := range s.data {
+			entries = append(entries, entry{key: key, value: value})
+		}
+		s.mutex.RUnlock()
+
+		for _, e := range entries {
+			if !action(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+func main() {
+	counters := NewShardedMap[string, int](16)
+
+	wg := sync.WaitGroup{}
+	wg.Add(100)
+	for i := 0; i < 100; i++ {
+		go func() {
+			defer wg.Done()
+			counters.Compute(fmt.Sprint("key_", i%3), func(value int, _ bool) (int, bool) {
+				return value + 1, true
+			})
+		}()
+	}
+
+	wg.Wait()
+
+	counters.Range(func(key string, value int) bool {
+		fmt.Println(key, value)
+		return true
+	})
+}
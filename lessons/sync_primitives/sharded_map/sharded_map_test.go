@@ -0,0 +1,96 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -race -v .
+
+func TestShardedMap(t *testing.T) {
+	data := NewShardedMap[string, int](5)
+	assert.Len(t, data.shards, 8)
+	assert.Zero(t, unsafe.Sizeof(data.shards[0])%cacheLineSize)
+
+	data.Store("a", 1)
+	data.Store("b", 2)
+
+	value, found := data.Load("a")
+	assert.True(t, found)
+	assert.Equal(t, 1, value)
+	_, found = data.Load("c")
+	assert.False(t, found)
+
+	value, loaded := data.LoadOrStore("b", 3)
+	assert.True(t, loaded)
+	assert.Equal(t, 2, value)
+	value, loaded = data.LoadOrStore("c", 3)
+	assert.False(t, loaded)
+	assert.Equal(t, 3, value)
+
+	value, found = data.Compute("a", func(value int, loaded bool) (int, bool) {
+		assert.True(t, loaded)
+		return value * 10, true
+	})
+	assert.True(t, found)
+	assert.Equal(t, 10, value)
+
+	_, found = data.Compute("b", func(int, bool) (int, bool) {
+		return 0, false
+	})
+	assert.False(t, found)
+
+	data.Delete("c")
+	assert.Equal(t, 1, data.Len())
+
+	entries := map[string]int{}
+	data.Range(func(key string, value int) bool {
+		data.Delete(key) // doesn't deadlock
+		entries[key] = value
+		return true
+	})
+	assert.Equal(t, map[string]int{"a": 10}, entries)
+	assert.Zero(t, data.Len())
+}
+
+func TestShardedMapStructKeys(t *testing.T) {
+	type point struct{ x, y int }
+
+	data := NewShardedMap[point, string](4)
+	data.Store(point{1, 2}, "first")
+	data.Store(point{2, 1}, "second")
+
+	value, _ := data.Load(point{1, 2})
+	assert.Equal(t, "first", value)
+	assert.Equal(t, 2, data.Len())
+}
+
+func TestShardedMapConcurrentCompute(t *testing.T) {
+	const goroutines = 8
+	const increments = 1000
+
+	data := NewShardedMap[int, int](16)
+
+	wg := sync.WaitGroup{}
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < increments; j++ {
+				data.Compute(j%10, func(value int, _ bool) (int, bool) {
+					return value + 1, true
+				})
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	data.Range(func(_ int, value int) bool {
+		assert.Equal(t, goroutines*increments/10, value)
+		return true
+	})
+}
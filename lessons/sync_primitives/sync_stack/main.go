@@ -2,49 +2,124 @@ package main
 
 import (
 	"sync"
+	"sync/atomic"
 )
 
-// Need to show solution
-
-type Stack struct {
+// Stack has pointer receivers: with value receivers every
+// call would lock and change its own copy of the mutex and data
+type Stack[T any] struct {
 	mutex sync.Mutex
-	data  []string
+	data  []T
 }
 
-func NewStack() Stack {
-	return Stack{}
+func NewStack[T any]() *Stack[T] {
+	return &Stack[T]{}
 }
 
-func (b Stack) Push(value string) {
-	b.mutex.Lock()
-	defer b.mutex.Unlock()
+func (s *Stack[T]) Push(value T) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 
-	b.data = append(b.data, value)
+	s.data = append(s.data, value)
 }
 
-func (b Stack) Pop() {
-	if len(b.data) < 0 {
-		panic("pop: stack is empty")
+// Pop checks emptiness under the lock, otherwise
+// the stack could become empty before the removal
+func (s *Stack[T]) Pop() (T, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var zero T
+	if len(s.data) == 0 {
+		return zero, false
 	}
 
-	b.mutex.Lock()
-	defer b.mutex.Unlock()
+	value := s.data[len(s.data)-1]
+	s.data[len(s.data)-1] = zero // don't hold a reference for GC
+	s.data = s.data[:len(s.data)-1]
+	return value, true
+}
+
+func (s *Stack[T]) Peek() (T, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.data) == 0 {
+		var zero T
+		return zero, false
+	}
+
+	return s.data[len(s.data)-1], true
+}
+
+func (s *Stack[T]) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.data)
+}
+
+type node[T any] struct {
+	value T
+	next  *node[T]
+}
+
+// LockFreeStack is a Treiber stack. ABA problem is impossible here:
+// every Push allocates a new node and GC doesn't reuse the memory
+// of a node while any goroutine still holds a pointer to it, so the
+// head can't return to the same address between Load and CompareAndSwap.
+// Pooling nodes would break this guarantee
+type LockFreeStack[T any] struct {
+	head atomic.Pointer[node[T]]
+	size atomic.Int64
+}
 
-	b.data = b.data[:len(b.data)-1]
+func NewLockFreeStack[T any]() *LockFreeStack[T] {
+	return &LockFreeStack[T]{}
 }
 
-func (b Stack) Top() string {
-	if len(b.data) < 0 {
-		panic("top: stack is empty")
+func (s *LockFreeStack[T]) Push(value T) {
+	n := &node[T]{value: value}
+	for {
+		n.next = s.head.Load()
+		if s.head.CompareAndSwap(n.next, n) {
+			s.size.Add(1)
+			return
+		}
 	}
+}
 
-	b.mutex.Lock()
-	defer b.mutex.Unlock()
+func (s *LockFreeStack[T]) Pop() (T, bool) {
+	for {
+		head := s.head.Load()
+		if head == nil {
+			var zero T
+			return zero, false
+		}
+
+		if s.head.CompareAndSwap(head, head.next) {
+			s.size.Add(-1)
+			return head.value, true
+		}
+	}
+}
+
+func (s *LockFreeStack[T]) Peek() (T, bool) {
+	head := s.head.Load()
+	if head == nil {
+		var zero T
+		return zero, false
+	}
+
+	return head.value, true
+}
 
-	return b.data[len(b.data)-1]
+// Len can be stale when the stack is changed concurrently
+func (s *LockFreeStack[T]) Len() int {
+	return int(s.size.Load())
 }
 
-var stack Stack
+var stack = NewStack[string]()
 
 func producer() {
 	for i := 0; i < 1000; i++ {
@@ -54,8 +129,7 @@ func producer() {
 
 func consumer() {
 	for i := 0; i < 10; i++ {
-		_ = stack.Top()
-		stack.Pop()
+		_, _ = stack.Pop()
 	}
 }
 
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test -race -v .
+// go test -bench=. .
+
+type ConcurrentStack interface {
+	Push(int)
+	Pop() (int, bool)
+	Peek() (int, bool)
+	Len() int
+}
+
+var stacks = []struct {
+	name   string
+	create func() ConcurrentStack
+}{
+	{"mutex", func() ConcurrentStack { return NewStack[int]() }},
+	{"lock_free", func() ConcurrentStack { return NewLockFreeStack[int]() }},
+}
+
+func TestStack(t *testing.T) {
+	for _, test := range stacks {
+		t.Run(test.name, func(t *testing.T) {
+			stack := test.create()
+			_, found := stack.Pop()
+			assert.False(t, found)
+			_, found = stack.Peek()
+			assert.False(t, found)
+
+			stack.Push(1)
+			stack.Push(2)
+			assert.Equal(t, 2, stack.Len())
+
+			value, _ := stack.Peek()
+			assert.Equal(t, 2, value)
+			value, _ = stack.Pop()
+			assert.Equal(t, 2, value)
+			value, _ = stack.Pop()
+			assert.Equal(t, 1, value)
+			assert.Zero(t, stack.Len())
+		})
+	}
+}
+
+func TestStackConcurrentAccess(t *testing.T) {
+	const goroutines = 8
+	const values = 1000
+
+	for _, test := range stacks {
+		t.Run(test.name, func(t *testing.T) {
+			stack := test.create()
+			popped := make([][]int, goroutines)
+
+			wg := sync.WaitGroup{}
+			wg.Add(goroutines)
+			for i := 0; i < goroutines; i++ {
+				go func() {
+					defer wg.Done()
+					for j := 0; j < values; j++ {
+						stack.Push(i*values + j)
+						if value, found := stack.Pop(); found {
+							popped[i] = append(popped[i], value)
+						}
+					}
+				}()
+			}
+
+			wg.Wait()
+
+			// every value is popped exactly once
+			seen := make(map[int]bool)
+			for _, values := range popped {
+				for _, value := range values {
+					assert.False(t, seen[value])
+					seen[value] = true
+				}
+			}
+
+			assert.Len(t, seen, goroutines*values)
+			assert.Zero(t, stack.Len())
+		})
+	}
+}
+
+func BenchmarkStack(b *testing.B) {
+	for _, test := range stacks {
+		b.Run(test.name, func(b *testing.B) {
+			stack := test.create()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					stack.Push(1)
+					stack.Pop()
+				}
+			})
+		})
+	}
+}